@@ -0,0 +1,86 @@
+package bloom
+
+// AuditFilter is a Bloom filter that also records every item added to it, so
+// that its contents can be enumerated, diffed or used to rebuild it, and its
+// false positives identified exactly.
+//
+// Recording the items defeats the purpose of a Bloom filter: an AuditFilter
+// uses far more memory than the items themselves. It is intended only for
+// debugging and testing, and should not be used in production.
+type AuditFilter[T comparable] struct {
+	filter *Filter[T]
+	items  []T            // distinct items, in the order first added
+	seen   map[T]struct{} // set of items
+}
+
+// NewAuditFilter creates a new, empty AuditFilter whose filter is created by
+// [NewBloomFilter] with the same arguments.
+//
+// It panics under the same conditions as [NewBloomFilter].
+func NewAuditFilter[T comparable](expectedItems uint, falsePositiveRate float64, opts ...Option) *AuditFilter[T] {
+	return &AuditFilter[T]{
+		filter: NewBloomFilter[T](expectedItems, falsePositiveRate, opts...),
+		seen:   make(map[T]struct{}),
+	}
+}
+
+// Add inserts an item into the filter, and records it.
+//
+// This method is not safe for concurrent use.
+func (af *AuditFilter[T]) Add(item T) {
+	af.filter.Add(item)
+	if _, ok := af.seen[item]; !ok {
+		af.seen[item] = struct{}{}
+		af.items = append(af.items, item)
+	}
+}
+
+// Contains tests whether an item might be in the filter, exactly like
+// [Filter.Contains]; false positives are not corrected.
+//
+// This method can be called concurrently with other calls to itself, but not
+// [AuditFilter.Add].
+func (af *AuditFilter[T]) Contains(item T) bool {
+	return af.filter.Contains(item)
+}
+
+// IsFalsePositive reports whether the filter reports item as present even
+// though it was never added.
+func (af *AuditFilter[T]) IsFalsePositive(item T) bool {
+	_, added := af.seen[item]
+	return !added && af.filter.Contains(item)
+}
+
+// Items returns a copy of the distinct items added to the filter, in the
+// order they were first added.
+func (af *AuditFilter[T]) Items() []T {
+	return append([]T(nil), af.items...)
+}
+
+// Reseed draws fresh random seeds for the underlying filter's hash functions,
+// and rebuilds its bits from the recorded items, such as when monitoring with
+// [Filter.UniformityScore] finds that the current seeds distribute items
+// badly. This relies on the AuditFilter having recorded every item in the
+// filter, so items added directly to [AuditFilter.Filter] are lost.
+//
+// The seeds are replaced as by [Filter.RegenerateSeeds], but the filter keeps
+// its entry count, and rebuilding doesn't call its hooks.
+//
+// This method is not safe for concurrent use.
+func (af *AuditFilter[T]) Reseed() {
+	bf := af.filter
+	entries := bf.entries
+	bf.RegenerateSeeds()
+	bf.entries = entries
+	for _, item := range af.items {
+		for _, seed := range bf.seeds {
+			wordIndex, mask := bf.location(bf.hashItem(item, seed))
+			bf.bits[wordIndex] |= mask
+		}
+	}
+}
+
+// Filter returns the underlying filter.
+func (af *AuditFilter[T]) Filter() *Filter[T] {
+	return af.filter
+}
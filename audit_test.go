@@ -0,0 +1,62 @@
+package bloom
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAuditFilter(t *testing.T) {
+	af := NewAuditFilter[int](100, 0.5)
+	for _, item := range []int{3, 1, 2, 1, 3} {
+		af.Add(item)
+	}
+
+	if got, want := af.Items(), []int{3, 1, 2}; !slices.Equal(got, want) {
+		t.Errorf("got items %v, want %v", got, want)
+	}
+	if got, want := af.Filter().entries, uint(5); got != want {
+		t.Errorf("got %d entries, want %d", got, want)
+	}
+
+	var falsePositives int
+	for i := range 1000 {
+		added := slices.Contains(af.Items(), i)
+		if added && af.IsFalsePositive(i) {
+			t.Errorf("%d was added, so it isn't a false positive", i)
+		}
+		if got, want := af.IsFalsePositive(i), !added && af.Contains(i); got != want {
+			t.Errorf("IsFalsePositive(%d) = %v, want %v", i, got, want)
+		}
+		if af.IsFalsePositive(i) {
+			falsePositives++
+		}
+	}
+	if falsePositives == 0 {
+		t.Error("expected some false positives at a 50% false positive rate")
+	}
+}
+
+func TestAuditFilter_Reseed(t *testing.T) {
+	var adds int
+	af := NewAuditFilter[int](1000, 0.01, WithHooks(Hooks{OnAdd: func() { adds++ }}))
+	for i := range 1000 {
+		af.Add(i)
+	}
+	seeds := af.Filter().Seeds()
+
+	af.Reseed()
+	if slices.Equal(af.Filter().Seeds(), seeds) {
+		t.Error("Reseed should change the seeds")
+	}
+	for i := range 1000 {
+		if !af.Contains(i) {
+			t.Fatalf("%d should still be in the filter", i)
+		}
+	}
+	if got, want := af.Filter().entries, uint(1000); got != want {
+		t.Errorf("got %d entries, want %d", got, want)
+	}
+	if adds != 1000 {
+		t.Errorf("got %d calls to OnAdd, want 1000", adds)
+	}
+}
@@ -3,56 +3,593 @@
 package bloom
 
 import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
 	"hash/maphash"
+	"iter"
 	"math"
+	"math/bits"
+	"math/rand/v2"
+	"reflect"
+	"slices"
 )
 
+// processSeed is the maphash seed that every hash function in this process is
+// derived from. Each filter mixes its own per-function seeds (see
+// [Filter.Seeds]) into hashes keyed by processSeed.
+var processSeed = maphash.MakeSeed()
+
 // Filter represents a space-efficient probabilistic data structure that tests
 // whether an element is a member of a set. Once a Filter has been created,
 // adding a new item to the set does not require any additional memory
 // allocation.
+//
+// Items are hashed with [maphash.WriteComparable], which guarantees that
+// items that are equal according to == hash identically. As a consequence:
+//
+//   - Values of a defined type, such as type Status int, hash the same as the
+//     equal value of their underlying type. This also applies to interface
+//     types: hashing an interface value doesn't take its dynamic type into
+//     account, so in a Filter[any], adding Status(1) causes Contains(int(1))
+//     to report true, even though the two values are not equal. Avoid mixing
+//     such types in one filter, or wrap them in distinct struct types.
+//   - Floating-point NaN values are never equal to themselves, so a NaN that
+//     has been added is not reported as present.
+//   - Pointers, channels and interfaces holding them are hashed by identity,
+//     not by the values they point to.
+//
+// The output of maphash may change between Go releases, but since it is also
+// randomized per process, a serialized filter can only be decoded by the
+// process that wrote it (see [ErrDifferentProcess]), which never spans a Go
+// upgrade. Filters created with [WithFNVHash] don't depend on the Go version.
 type Filter[T comparable] struct {
 	bits    []uint64
-	m       uint           // size of bit array
-	seeds   []maphash.Seed // k different seeds for k hash functions
+	m       uint     // size of bit array
+	seeds   []uint64 // k different seeds for k hash functions
 	entries uint
+
+	noEntries bool // if set, entries is not maintained
+	fnv       bool // if set, hash with FNV-1a rather than maphash
+	distinct  bool // if set, only count items that weren't already present
+
+	domain string // mixed into seeds; see WithDomain
+
+	hooks      Hooks             // called on Add and Contains, if set
+	saturation *saturationCheck  // if set, checked on Add and Contains
+	interval   *intervalCounters // if set, counts calls to Add and Contains
+
+	// The highest fill ratio before the filter was last reset.
+	peakFill float64
+
+	// The number of times the filter's bits have been cleared, by Reset or
+	// by decoding into it, so that caches of its results can tell when they
+	// are stale.
+	resets uint64
+
+	// The parameters the filter was sized for, if known, or zero.
+	expectedItems uint
+	targetFPR     float64
 }
 
 // NewBloomFilter creates a new Bloom filter optimized for the expected number
 // of items and desired false positive rate.
-func NewBloomFilter[T comparable](expectedItems uint, falsePositiveRate float64) *Filter[T] {
+//
+// An expectedItems of zero is treated as one, and a falsePositiveRate of one
+// or more produces the smallest possible filter. NewBloomFilter panics if
+// falsePositiveRate is NaN or not greater than zero, or if the resulting
+// filter would be too large to allocate.
+func NewBloomFilter[T comparable](expectedItems uint, falsePositiveRate float64, opts ...Option) *Filter[T] {
+	o := makeOptions(opts)
+
 	// Calculate optimal size and number of hash functions
 	m, k := bloomParams(expectedItems, falsePositiveRate)
 
-	// Generate k different seeds
-	seeds := make([]maphash.Seed, k)
-	for i := range seeds {
-		seeds[i] = maphash.MakeSeed()
+	return newFilter[T](m, o.newSeeds(k), o).sizedFor(expectedItems, falsePositiveRate)
+}
+
+// NewDistinctFilter creates a new Bloom filter like [NewBloomFilter], but
+// whose [Filter.Add] only counts an item towards the number of entries if it
+// wasn't already present, as reported by [Filter.TestAndAdd]. The entry count
+// is then an estimate of the number of distinct items added, which keeps
+// [Filter.EstimatedFalsePositiveRate] accurate when items are added more than
+// once, at the cost of a slightly slower Add.
+//
+// The count is an underestimate, since a new item that is a false positive
+// isn't counted.
+//
+// It panics under the same conditions as [NewBloomFilter].
+func NewDistinctFilter[T comparable](expectedItems uint, falsePositiveRate float64, opts ...Option) *Filter[T] {
+	bf := NewBloomFilter[T](expectedItems, falsePositiveRate, opts...)
+	bf.distinct = true
+	return bf
+}
+
+// NewFromSeq creates a new Bloom filter sized for expectedItems at the desired
+// false positive rate, and adds every item yielded by seq.
+//
+// It panics under the same conditions as [NewBloomFilter].
+func NewFromSeq[T comparable](seq iter.Seq[T], expectedItems uint, falsePositiveRate float64, opts ...Option) *Filter[T] {
+	bf := NewBloomFilter[T](expectedItems, falsePositiveRate, opts...)
+	for item := range seq {
+		bf.Add(item)
+	}
+	return bf
+}
+
+// newFilter creates a new, empty Bloom filter with an m-bit array and hash
+// functions derived from seeds, which it takes ownership of and mixes the
+// domain into.
+func newFilter[T comparable](m uint, seeds []uint64, o options) *Filter[T] {
+	if o.fnvHash {
+		if err := checkFNVType(reflect.TypeFor[T]()); err != nil {
+			panic(err)
+		}
 	}
 
+	o.applyDomain(seeds)
+
 	bf := &Filter[T]{
 		bits:    make([]uint64, (m+63)/64), // Round up to nearest multiple of 64
 		m:       m,
 		seeds:   seeds,
 		entries: 0,
+
+		noEntries: o.noEntryCounting,
+		fnv:       o.fnvHash,
+		domain:    o.domain,
+	}
+	setObservers(bf, o)
+	return bf
+}
+
+// setObservers sets bf's hooks, saturation check and interval stats from o.
+func setObservers[T comparable](bf *Filter[T], o options) {
+	bf.hooks = o.hooks
+	if o.saturationInterval > 0 {
+		bf.saturation = &saturationCheck{interval: uint64(o.saturationInterval)}
 	}
+	if o.intervalStats {
+		bf.interval = new(intervalCounters)
+	}
+}
+
+// sizedFor records the parameters that bf was sized for, and returns bf.
+func (bf *Filter[T]) sizedFor(expectedItems uint, falsePositiveRate float64) *Filter[T] {
+	bf.expectedItems = max(expectedItems, 1)
+	bf.targetFPR = min(falsePositiveRate, 1)
 	return bf
 }
 
+// randomSeeds generates seeds for k different hash functions.
+func randomSeeds(k uint) []uint64 {
+	seeds := make([]uint64, k)
+	for i := range seeds {
+		seeds[i] = rand.Uint64()
+	}
+	return seeds
+}
+
+// NewBloomFilterForLoad creates a new Bloom filter sized for expectedItems at
+// the desired false positive rate, but using the fewest hash functions that
+// still achieve that rate when only expectedLoad items have been added. This
+// reduces the hashing work done by [Filter.Add] and [Filter.Contains] for
+// filters that are known to be over-provisioned.
+//
+// As long as no more than expectedLoad items are added, the false positive
+// rate stays below falsePositiveRate. Beyond that point the rate rises faster
+// than it would for a filter from [NewBloomFilter], and may exceed
+// falsePositiveRate well before expectedItems items have been added; use
+// [Filter.EstimatedFalsePositiveRate] to monitor it.
+//
+// It panics under the same conditions as [NewBloomFilter].
+func NewBloomFilterForLoad[T comparable](expectedItems uint, falsePositiveRate float64, expectedLoad uint, opts ...Option) *Filter[T] {
+	m, k := bloomParams(expectedItems, falsePositiveRate)
+	k = minHashFunctions(m, k, expectedLoad, falsePositiveRate)
+	o := makeOptions(opts)
+	return newFilter[T](m, o.newSeeds(k), o).sizedFor(expectedItems, falsePositiveRate)
+}
+
+// NewBloomFilterFixedK creates a new Bloom filter that uses exactly k hash
+// functions, with a bit array sized so that the filter has the desired false
+// positive rate once expectedItems items have been added. This is useful for
+// interoperating with systems that fix the number of hash functions rather
+// than choosing the optimal number.
+//
+// It panics if k is zero, and otherwise under the same conditions as
+// [NewBloomFilter].
+func NewBloomFilterFixedK[T comparable](expectedItems uint, falsePositiveRate float64, k uint, opts ...Option) *Filter[T] {
+	if k == 0 {
+		panic("bloom: k must be at least 1")
+	}
+	m := bitsForFixedK(expectedItems, falsePositiveRate, k)
+	o := makeOptions(opts)
+	return newFilter[T](m, o.newSeeds(k), o).sizedFor(expectedItems, falsePositiveRate)
+}
+
+// bitsForFixedK returns the number of bits needed for a filter with k hash
+// functions to have a false positive rate of p after n items are added.
+func bitsForFixedK(expectedItems uint, falsePositiveRate float64, k uint) uint {
+	n := float64(max(expectedItems, 1))
+	p := checkFalsePositiveRate(falsePositiveRate)
+
+	// Solving p = (1 - e^(-kn/m))^k for m gives:
+	//    m = -kn / ln(1 - p^(1/k))
+	kf := float64(k)
+	m := -kf * n / math.Log(1-math.Pow(p, 1/kf))
+	return checkBits(m)
+}
+
+// NewBloomFilterPow2 creates a new Bloom filter like [NewBloomFilter], but with
+// the size of its bit array rounded up to the next power of two, using the
+// optimal number of hash functions for that size. The false positive rate is
+// therefore at most falsePositiveRate, at the cost of up to twice the memory.
+//
+// Since bit positions are computed with a multiply-shift rather than a modulo
+// (see [Filter.HashPositions]), a power-of-two size doesn't remove any
+// divisions from [Filter.Add] or [Filter.Contains]; it simply maps each hash
+// to its top bits. This is mainly useful for interoperating with systems that
+// require power-of-two sizes.
+//
+// It panics under the same conditions as [NewBloomFilter].
+func NewBloomFilterPow2[T comparable](expectedItems uint, falsePositiveRate float64, opts ...Option) *Filter[T] {
+	m, _ := bloomParams(expectedItems, falsePositiveRate)
+	m = 1 << bits.Len(m-1)
+
+	n := float64(max(expectedItems, 1))
+	k := max(uint(math.Round(float64(m)/n*math.Ln2)), 1)
+
+	o := makeOptions(opts)
+	return newFilter[T](m, o.newSeeds(k), o).sizedFor(expectedItems, falsePositiveRate)
+}
+
+// NewBloomFilterBitsPerElement creates a new Bloom filter with bitsPerElement
+// bits for each of expectedItems items, using the optimal number of hash
+// functions for that ratio, round(bitsPerElement·ln 2). For example, 10 bits
+// per element gives a false positive rate of about 1%. The rate the filter
+// achieves at expectedItems is reported by [Filter.TargetFalsePositiveRate].
+//
+// It panics if bitsPerElement is NaN or not greater than zero, or if the
+// resulting filter would be too large to allocate.
+func NewBloomFilterBitsPerElement[T comparable](expectedItems uint, bitsPerElement float64, opts ...Option) *Filter[T] {
+	if !(bitsPerElement > 0) {
+		panic("bloom: bits per element must be greater than zero")
+	}
+	n := max(expectedItems, 1)
+	m := checkBits(float64(n) * bitsPerElement)
+	k := max(uint(math.Round(bitsPerElement*math.Ln2)), 1)
+
+	o := makeOptions(opts)
+	return newFilter[T](m, o.newSeeds(k), o).sizedFor(n, FalsePositiveRate(m, k, n))
+}
+
+// NewBloomFilterInRange creates a new Bloom filter with the smallest bit array
+// for which the false positive rate once expectedItems items have been added
+// is between minFPR and maxFPR, for when any rate in that range is acceptable.
+// Since a higher rate needs fewer bits, this is usually the filter sized for
+// maxFPR with the better of the two whole numbers of hash functions nearest
+// the optimum; unlike [NewBloomFilter], rounding never takes the rate above
+// maxFPR. For small expectedItems, where a single bit changes the rate
+// considerably, other numbers of hash functions are tried until the rate
+// falls in the range. The chosen rate is reported by
+// [Filter.TargetFalsePositiveRate].
+//
+// It panics unless 0 < minFPR < maxFPR < 1, if no filter has a rate in the
+// range, as can happen for a narrow range and few items, or if the resulting
+// filter would be too large to allocate.
+func NewBloomFilterInRange[T comparable](expectedItems uint, minFPR, maxFPR float64, opts ...Option) *Filter[T] {
+	if !(minFPR > 0 && minFPR < maxFPR && maxFPR < 1) {
+		panic(fmt.Sprintf("bloom: invalid false positive rate range [%v, %v]", minFPR, maxFPR))
+	}
+	n := max(expectedItems, 1)
+
+	// The optimal number of hash functions is -log2(p). Far from it, the
+	// bit array only grows, so the search stops at about twice that.
+	maxK := 2*uint(math.Ceil(-math.Log2(maxFPR))) + 1
+	var m, k uint
+	for ck := uint(1); ck <= maxK; ck++ {
+		// The smallest bit array for which the rate is at most maxFPR.
+		cm := bitsForFixedK(n, maxFPR, ck)
+		for cm > 1 && FalsePositiveRate(cm-1, ck, n) <= maxFPR {
+			cm--
+		}
+		for FalsePositiveRate(cm, ck, n) > maxFPR {
+			cm++
+		}
+		if FalsePositiveRate(cm, ck, n) >= minFPR && (m == 0 || cm < m) {
+			m, k = cm, ck
+		}
+	}
+	if m == 0 {
+		panic(fmt.Sprintf("bloom: no filter for %d items has a false positive rate in [%v, %v]", n, minFPR, maxFPR))
+	}
+
+	o := makeOptions(opts)
+	return newFilter[T](m, o.newSeeds(k), o).sizedFor(n, FalsePositiveRate(m, k, n))
+}
+
+// minHashFunctions returns the smallest number of hash functions, no greater
+// than maxK, such that an m-bit filter containing n items has a false positive
+// rate no greater than p.
+func minHashFunctions(m, maxK, n uint, p float64) uint {
+	for k := uint(1); k < maxK; k++ {
+		if FalsePositiveRate(m, k, n) <= p {
+			return k
+		}
+	}
+	return maxK
+}
+
+// NewBloomFilterWithSeeds creates a new, empty Bloom filter with an m-bit array
+// and k hash functions derived from the provided seeds, as returned by
+// [Filter.Seeds]. It returns an error if m or k is zero, or if len(seeds) != k.
+//
+// Two filters created with the same m and seeds hash items identically, as
+// long as they are used within the same process; the underlying
+// [hash/maphash] hashes are randomized per-process, so seeds cannot be used to
+// recreate a filter's hashing in a different process. Filters using
+// [WithFNVHash] hash identically in any process.
+func NewBloomFilterWithSeeds[T comparable](m, k uint, seeds []uint64, opts ...Option) (*Filter[T], error) {
+	if m == 0 {
+		return nil, errors.New("bloom: m must be at least 1")
+	}
+	if k == 0 {
+		return nil, errors.New("bloom: k must be at least 1")
+	}
+	if uint(len(seeds)) != k {
+		return nil, errors.New("bloom: number of seeds must equal k")
+	}
+	return newFilter[T](m, append([]uint64(nil), seeds...), makeOptions(opts)), nil
+}
+
+// NewLike creates a new, empty Bloom filter with the same configuration as
+// template: the same size, hash functions and options, such as hooks. The
+// result is always compatible with template for [Filter.Union], which makes
+// it suitable as an accumulator for filters built elsewhere. Neither the bits
+// nor the entry count of template are copied, and the counts kept for
+// [WithSaturationCheck] and [WithIntervalStats] start from zero.
+func NewLike[T comparable](template *Filter[T]) *Filter[T] {
+	return template.emptyCopy()
+}
+
+// Clone returns a copy of bf, with the same configuration as [NewLike] and the
+// same bits, entry count and counts for [WithSaturationCheck] and
+// [WithIntervalStats]. The copy is independent of bf: later changes to either
+// don't affect the other.
+//
+// Since the clone's entry count starts equal to bf's, counting every item bf
+// held, combining the two again with [Filter.Union] counts those items twice.
+// Use [Filter.UnionDistinct] to combine filters that have items in common.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) Clone() *Filter[T] {
+	clone := bf.emptyCopy()
+	copy(clone.bits, bf.bits)
+	clone.entries = bf.entries
+	clone.peakFill = bf.peakFill
+	clone.addCounters(bf)
+	return clone
+}
+
+// Seeds returns a copy of the seeds for the filter's hash functions, suitable
+// for passing to [NewBloomFilterWithSeeds].
+func (bf *Filter[T]) Seeds() []uint64 {
+	return append([]uint64(nil), bf.seeds...)
+}
+
+// BitsLen returns the number of bits in the filter's bit array.
+func (bf *Filter[T]) BitsLen() uint {
+	return bf.m
+}
+
+// BitAt reports whether bit i of the filter's bit array is set. It panics if
+// i >= [Filter.BitsLen].
+func (bf *Filter[T]) BitAt(i uint) bool {
+	if i >= bf.m {
+		panic(fmt.Sprintf("bloom: bit index %d out of range [0, %d)", i, bf.m))
+	}
+	return bf.bits[i/64]&(1<<(i%64)) != 0
+}
+
+// Bits returns a copy of the filter's bit array. Bit i is stored in word i/64
+// at bit position i%64, counting from the least significant bit, which is the
+// layout used by most bitset libraries; bits at or beyond [Filter.BitsLen]
+// are always zero.
+func (bf *Filter[T]) Bits() []uint64 {
+	words := append([]uint64(nil), bf.bits...)
+	words[len(words)-1] &= bf.tailMask()
+	return words
+}
+
+// Words returns an iterator over the words of the filter's bit array and
+// their indices, in the layout described by [Filter.Bits], for computing
+// aggregates in a single pass without copying the array. Bits at or beyond
+// [Filter.BitsLen] are cleared in the final word.
+//
+// Iterating can be done concurrently with calls to [Filter.Contains], but not
+// [Filter.Add].
+func (bf *Filter[T]) Words() iter.Seq2[int, uint64] {
+	return func(yield func(int, uint64) bool) {
+		last := len(bf.bits) - 1
+		for i, word := range bf.bits {
+			if i == last {
+				word &= bf.tailMask()
+			}
+			if !yield(i, word) {
+				return
+			}
+		}
+	}
+}
+
+// ConfigHash returns a token identifying the filter's configuration: the size
+// of its bit array, its hashing scheme, and its hash functions. Two filters
+// can be combined, such as by a union or intersection of their bits, if and
+// only if their ConfigHash values are equal.
+//
+// The token is stable across processes, so it can be used to key serialized
+// filters by configuration.
+func (bf *Filter[T]) ConfigHash() uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	var scheme uint64
+	if bf.fnv {
+		scheme = 1
+	}
+	for _, v := range append([]uint64{uint64(bf.m), scheme, uint64(len(bf.seeds))}, bf.seeds...) {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
 // Add inserts an item into the Bloom filter.
 //
 // This method is not safe for concurrent use.
 func (bf *Filter[T]) Add(item T) {
-	bf.entries++
+	bf.add(item)
+}
+
+// TestAndAdd inserts an item into the Bloom filter, like [Filter.Add], and
+// reports whether it might already have been present, as [Filter.Contains]
+// would have before the call.
+//
+// This method is not safe for concurrent use.
+func (bf *Filter[T]) TestAndAdd(item T) bool {
+	return bf.add(item)
+}
+
+// add implements [Filter.Add] and [Filter.TestAndAdd].
+func (bf *Filter[T]) add(item T) (present bool) {
+	bf.startAdd()
 
 	// Set a bit for each of our hash functions.
+	present = true
 	for _, seed := range bf.seeds {
 		hash := bf.hashItem(item, seed)
-		combinedHash := hash % uint64(bf.m)
-		wordIndex := combinedHash / 64
-		bitOffset := combinedHash % 64
-		bf.bits[wordIndex] |= 1 << bitOffset
+		wordIndex, mask := bf.location(hash)
+		present = present && bf.bits[wordIndex]&mask != 0
+		bf.bits[wordIndex] |= mask
 	}
+
+	bf.finishAdd(present)
+	return present
+}
+
+// startAdd calls the hooks and updates the counters for an item being added.
+func (bf *Filter[T]) startAdd() {
+	if bf.hooks.OnAdd != nil {
+		bf.hooks.OnAdd()
+	}
+	if bf.saturation != nil {
+		bf.checkSaturation(1)
+	}
+	if bf.interval != nil {
+		bf.interval.adds.Add(1)
+	}
+}
+
+// finishAdd counts an item that has been added, given whether it was already
+// present.
+func (bf *Filter[T]) finishAdd(present bool) {
+	if !bf.noEntries && !(bf.distinct && present) {
+		bf.entries++
+	}
+}
+
+// addReturningNewBits inserts an item with [Filter.Add], and returns the bit
+// position set for each hash function, along with how many of those bits were
+// not already set. It's intended for tests of the hashing core.
+func (bf *Filter[T]) addReturningNewBits(item T) (positions []uint, newBits int) {
+	positions = bf.HashPositions(item)
+	for i, pos := range positions {
+		// Two hash functions may map to the same new bit; count it once.
+		if bf.bits[pos>>6]&(1<<(pos&63)) == 0 && !slices.Contains(positions[:i], pos) {
+			newBits++
+		}
+	}
+	bf.add(item)
+	return positions, newBits
+}
+
+// Reset removes every item from the filter, keeping its configuration. The
+// filter's [Filter.PeakFillRatio] is preserved.
+//
+// This method is not safe for concurrent use.
+func (bf *Filter[T]) Reset() {
+	bf.peakFill = bf.PeakFillRatio()
+	clear(bf.bits)
+	bf.entries = 0
+	bf.resets++
+}
+
+// RegenerateSeeds replaces the seeds of the filter's hash functions with fresh
+// random ones, such as after [Filter.UniformityScore] finds that the current
+// seeds distribute items badly, and resets the filter like [Filter.Reset],
+// since bits set under the old seeds are meaningless under the new ones. Every
+// item must be added again afterwards. [AuditFilter.Reseed] does so
+// automatically.
+//
+// The filter keeps its size, number of hash functions and options, so it
+// serializes in the same format, readable by the same versions of this
+// package. Since the seeds are random, a filter created with [WithFNVHash] no
+// longer hashes items identically to other filters of the same size; use
+// [Filter.Seeds] to recreate it.
+//
+// This method is not safe for concurrent use.
+func (bf *Filter[T]) RegenerateSeeds() {
+	bf.seeds = randomSeeds(uint(len(bf.seeds)))
+	bf.Reset()
+}
+
+// AddUnique inserts each of the provided items into the Bloom filter, ignoring
+// duplicates within items so that each distinct item only counts once towards
+// the number of entries used by [Filter.EstimatedFalsePositiveRate]. Items
+// that were added before this call are still counted again.
+//
+// This allocates a temporary set of the distinct items.
+//
+// This method is not safe for concurrent use.
+func (bf *Filter[T]) AddUnique(items []T) {
+	seen := make(map[T]struct{}, len(items))
+	for _, item := range items {
+		if _, ok := seen[item]; ok {
+			continue
+		}
+		seen[item] = struct{}{}
+		bf.Add(item)
+	}
+}
+
+// addBatchCheckInterval is how many items AddBatchContext adds between checks
+// for cancellation.
+const addBatchCheckInterval = 1024
+
+// AddBatchContext inserts each of the provided items into the Bloom filter,
+// checking periodically whether ctx has been cancelled.
+//
+// If ctx is done before all items have been added, AddBatchContext stops and
+// returns ctx.Err(). In that case a prefix of items will have been added to
+// the filter; since items can't be removed, the caller should discard the
+// filter if a partial result is unacceptable.
+//
+// This method is not safe for concurrent use.
+func (bf *Filter[T]) AddBatchContext(ctx context.Context, items []T) error {
+	for len(items) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n := min(len(items), addBatchCheckInterval)
+		for _, item := range items[:n] {
+			bf.Add(item)
+		}
+		items = items[n:]
+	}
+	return nil
 }
 
 // Contains tests whether an item might be in the set.
@@ -61,36 +598,396 @@ func (bf *Filter[T]) Add(item T) {
 // This method can be called concurrently with other calls to itself or
 // [Filter.EstimatedFalsePositiveRate], but not [Filter.Add].
 func (bf *Filter[T]) Contains(item T) bool {
+	found := bf.contains(item)
+	bf.finishContains(found)
+	return found
+}
+
+// finishContains calls the hooks and updates the counters for a query with
+// the given result.
+func (bf *Filter[T]) finishContains(found bool) {
+	if bf.hooks.OnContains != nil {
+		bf.hooks.OnContains(found)
+	}
+	if bf.saturation != nil {
+		bf.checkSaturation(1)
+	}
+	if bf.interval != nil {
+		bf.interval.contains.Add(1)
+		if found {
+			bf.interval.positives.Add(1)
+		}
+	}
+}
+
+// DefinitelyAbsent reports whether item has definitely never been added to the
+// filter. It is the negation of [Filter.Contains], named for the one
+// conclusion a Bloom filter guarantees: a true result is certain, while a
+// false result only means the item might have been added.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) DefinitelyAbsent(item T) bool {
+	return !bf.Contains(item)
+}
+
+// contains implements [Filter.Contains], without calling hooks.
+func (bf *Filter[T]) contains(item T) bool {
 	// Check all k positions
 	for _, seed := range bf.seeds {
 		hash := bf.hashItem(item, seed)
-		combinedHash := hash % uint64(bf.m)
-		wordIndex := combinedHash / 64
-		bitOffset := combinedHash % 64
-		if bf.bits[wordIndex]&(1<<bitOffset) == 0 {
+		wordIndex, mask := bf.location(hash)
+		if bf.bits[wordIndex]&mask == 0 {
 			return false
 		}
 	}
 	return true
 }
 
+// ContainsProbability returns the probability that item is in the set, given
+// the filter's current load: zero if the item is definitely not present, or
+// one minus [Filter.ActualFalsePositiveRate] if it might be.
+//
+// This method can be called concurrently with other calls to
+// [Filter.Contains], but not [Filter.Add].
+func (bf *Filter[T]) ContainsProbability(item T) float64 {
+	if !bf.Contains(item) {
+		return 0
+	}
+	return 1 - bf.ActualFalsePositiveRate()
+}
+
+// AddSum inserts an item into the Bloom filter, given a precomputed 64-bit
+// hash of that item. The k bit positions are derived from sum using double
+// hashing, rather than by hashing the item k times.
+//
+// Items added using AddSum must be queried using [Filter.ContainsSum]; they
+// will not be reported by [Filter.Contains], and vice versa. The caller is
+// responsible for ensuring that sum is a well-distributed hash.
+//
+// Otherwise, AddSum behaves like [Filter.Add]: it calls the [Hooks.OnAdd] hook
+// and counts towards [WithSaturationCheck] and [WithIntervalStats], and a
+// filter created with [NewDistinctFilter] only counts it if it wasn't already
+// present.
+//
+// This method is not safe for concurrent use.
+func (bf *Filter[T]) AddSum(sum uint64) {
+	bf.startAdd()
+
+	present := true
+	h1, h2 := doubleHashes(sum)
+	for i := range uint64(len(bf.seeds)) {
+		wordIndex, mask := bf.location(h1 + i*h2)
+		present = present && bf.bits[wordIndex]&mask != 0
+		bf.bits[wordIndex] |= mask
+	}
+
+	bf.finishAdd(present)
+}
+
+// ContainsSum tests whether an item might be in the set, given the same
+// precomputed 64-bit hash that was passed to [Filter.AddSum].
+//
+// Otherwise, ContainsSum behaves like [Filter.Contains]: it calls the
+// [Hooks.OnContains] hook and counts towards [WithSaturationCheck] and
+// [WithIntervalStats].
+//
+// This method can be called concurrently with other calls to itself or
+// [Filter.Contains], but not [Filter.Add] or [Filter.AddSum].
+func (bf *Filter[T]) ContainsSum(sum uint64) bool {
+	found := true
+	h1, h2 := doubleHashes(sum)
+	for i := range uint64(len(bf.seeds)) {
+		wordIndex, mask := bf.location(h1 + i*h2)
+		if bf.bits[wordIndex]&mask == 0 {
+			found = false
+			break
+		}
+	}
+	bf.finishContains(found)
+	return found
+}
+
+// doubleHashes derives the two hashes used for double hashing from a single
+// 64-bit hash, as described by Kirsch and Mitzenmacher in "Less Hashing, Same
+// Performance: Building a Better Bloom Filter". The i-th hash function is then
+// h1 + i*h2.
+func doubleHashes(sum uint64) (h1, h2 uint64) {
+	// Use the SplitMix64 finalizer to derive a second hash that's
+	// independent of the first, and make it odd so that it never
+	// degenerates to a single position.
+	return sum, mix64(sum) | 1
+}
+
+// mix64 is the SplitMix64 finalizer, which thoroughly mixes the bits of x.
+func mix64(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// location returns the index of the word in bits, and the mask within that
+// word, that the given hash maps to.
+func (bf *Filter[T]) location(hash uint64) (wordIndex uint64, mask uint64) {
+	pos := bf.position(hash)
+	return pos >> 6, 1 << (pos & 63)
+}
+
+// position returns the index of the bit that the given hash maps to.
+func (bf *Filter[T]) position(hash uint64) uint64 {
+	return reduce(hash, bf.m)
+}
+
+// reduce maps hash onto [0, m).
+func reduce(hash uint64, m uint) uint64 {
+	// Use a multiply-shift rather than a modulo; this avoids a division,
+	// and uses the high bits of the hash rather than the low bits. See:
+	//    https://lemire.me/blog/2016/06/27/a-fast-alternative-to-the-modulo-reduction/
+	pos, _ := bits.Mul64(hash, uint64(m))
+	return pos
+}
+
+// HashPositions returns the indices of the k bits that item maps to, in the
+// order of the filter's hash functions. An item is reported as present by
+// [Filter.Contains] exactly when all of these bits are set, which makes this
+// useful for diagnosing why a particular false positive occurred.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) HashPositions(item T) []uint {
+	positions := make([]uint, len(bf.seeds))
+	for i, seed := range bf.seeds {
+		positions[i] = uint(bf.position(bf.hashItem(item, seed)))
+	}
+	return positions
+}
+
+// RawHashes returns the k 64-bit hashes of item that the filter computes, in
+// the order of the filter's hash functions, before each is reduced to the bit
+// position returned by [Filter.HashPositions]. This is a low-level diagnostic
+// for comparing the filter's hashing with another implementation's.
+//
+// With [WithFNVHash], each hash is the FNV-1a hash of the item's canonical
+// encoding, starting from the FNV offset basis XORed with the hash function's
+// seed, followed by the SplitMix64 finalizer. Otherwise the hashes come from
+// [hash/maphash], and are only meaningful within the current process.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) RawHashes(item T) []uint64 {
+	hashes := make([]uint64, len(bf.seeds))
+	for i, seed := range bf.seeds {
+		hashes[i] = bf.hashItem(item, seed)
+	}
+	return hashes
+}
+
+// CountPresent tests each of the provided items for membership, returning the
+// number of items for which [Filter.Contains] reports true, and an estimate of
+// how many of those are true positives.
+//
+// The estimate corrects for the expected number of false positives, based on
+// [Filter.EstimatedFalsePositiveRate]: if t of the n items are truly present,
+// we expect present ≈ t + (n-t)*p, which we solve for t.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) CountPresent(items []T) (present int, estimatedTruePositives float64) {
+	for _, item := range items {
+		if bf.Contains(item) {
+			present++
+		}
+	}
+
+	p := bf.EstimatedFalsePositiveRate()
+	if p >= 1 {
+		// Every query is a positive; we can't distinguish anything.
+		return present, 0
+	}
+
+	n := float64(len(items))
+	estimatedTruePositives = (float64(present) - n*p) / (1 - p)
+	estimatedTruePositives = min(max(estimatedTruePositives, 0), float64(present))
+	return present, estimatedTruePositives
+}
+
+// containsManyGroup is how many items ContainsMany tests together. It must
+// divide 64.
+const containsManyGroup = 16
+
+// ContainsMany tests each of the provided items for membership, returning the
+// results as a packed bitset where bit i is set if items[i] might be in the
+// set. Use [ResultAt] to read individual results.
+//
+// Items are tested in groups, checking one bit of every item in a group
+// before the next, so that the memory accesses for different items are
+// independent and the CPU can overlap their cache misses. For large filters,
+// this is considerably faster than calling [Filter.Contains] in a loop.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) ContainsMany(items []T) []uint64 {
+	result := make([]uint64, (len(items)+63)/64)
+	for start := 0; start < len(items); start += containsManyGroup {
+		group := items[start:min(start+containsManyGroup, len(items))]
+
+		// Bit i of present is set while group[i] might be in the set.
+		present := uint64(1)<<len(group) - 1
+		var words, masks [containsManyGroup]uint64
+		for _, seed := range bf.seeds {
+			// Compute every location before loading any of them.
+			for remaining := present; remaining != 0; remaining &= remaining - 1 {
+				i := bits.TrailingZeros64(remaining)
+				words[i], masks[i] = bf.location(bf.hashItem(group[i], seed))
+			}
+			for remaining := present; remaining != 0; remaining &= remaining - 1 {
+				i := bits.TrailingZeros64(remaining)
+				if bf.bits[words[i]]&masks[i] == 0 {
+					present &^= 1 << i
+				}
+			}
+			if present == 0 {
+				break
+			}
+		}
+
+		// Groups are aligned to words of result.
+		result[start/64] |= present << (start % 64)
+		if bf.hooks.OnContains != nil {
+			for i := range group {
+				bf.hooks.OnContains(present&(1<<i) != 0)
+			}
+		}
+		if bf.saturation != nil {
+			bf.checkSaturation(uint64(len(group)))
+		}
+		if bf.interval != nil {
+			bf.interval.contains.Add(uint64(len(group)))
+			bf.interval.positives.Add(uint64(bits.OnesCount64(present)))
+		}
+	}
+	return result
+}
+
+// ResultAt reports whether bit i is set in a bitset returned by
+// [Filter.ContainsMany].
+func ResultAt(result []uint64, i int) bool {
+	return result[i/64]&(1<<(i%64)) != 0
+}
+
 // hashItem generates a hash value using the provided seed
-func (bf *Filter[T]) hashItem(item T, seed maphash.Seed) uint64 {
+func (bf *Filter[T]) hashItem(item T, seed uint64) uint64 {
+	if bf.fnv {
+		return fnvHash(item, seed)
+	}
+	return maphashItem(item, seed)
+}
+
+// maphashItem hashes item with maphash, using the hash function identified by
+// seed.
+func maphashItem[T comparable](item T, seed uint64) uint64 {
 	var hasher maphash.Hash
-	hasher.SetSeed(seed)
+	hasher.SetSeed(processSeed)
+	maphash.WriteComparable(&hasher, seed)
 	maphash.WriteComparable(&hasher, item)
 	return hasher.Sum64()
 }
 
+// TargetFalsePositiveRate returns the false positive rate the filter was
+// designed to have once it holds the number of items it was sized for, or
+// zero if that isn't known, such as for filters created by
+// [NewBloomFilterWithSeeds]. Comparing it with
+// [Filter.EstimatedFalsePositiveRate] shows how far the filter has drifted
+// from its design.
+func (bf *Filter[T]) TargetFalsePositiveRate() float64 {
+	return bf.targetFPR
+}
+
 // EstimatedFalsePositiveRate returns the current estimated false positive rate
 // based on the number of items added.
 //
+// If the filter was created with [WithoutEntryCounting], the rate is instead
+// estimated from the fraction of bits that are set.
+//
 // This method can be called concurrently with other calls to [Filter.Contains]
 // or itself.
 func (bf *Filter[T]) EstimatedFalsePositiveRate() float64 {
-	if bf.entries == 0 {
+	if bf.noEntries {
+		return bf.ActualFalsePositiveRate()
+	}
+	return FalsePositiveRate(bf.m, uint(len(bf.seeds)), bf.entries)
+}
+
+// ActualFalsePositiveRate returns the false positive rate implied by the
+// fraction of bits that are currently set: a query for an item that hasn't
+// been added checks k effectively random bits, and is a false positive if all
+// of them are set.
+//
+// Unlike [Filter.EstimatedFalsePositiveRate], this doesn't depend on the number
+// of items added, so it stays accurate when items are added more than once or
+// when filters have been combined. It is the better measure of a filter's
+// current state; EstimatedFalsePositiveRate is cheaper, and reflects what the
+// rate is expected to be given the number of items added.
+//
+// This method can be called concurrently with other calls to [Filter.Contains]
+// or itself.
+func (bf *Filter[T]) ActualFalsePositiveRate() float64 {
+	// The expected fraction of set bits after n insertions is
+	// 1 - e^(-kn/m), so this is the observed equivalent of the formula used
+	// by FalsePositiveRate.
+	fractionSet := float64(bf.validBitCount()) / float64(bf.m)
+	return math.Pow(fractionSet, float64(len(bf.seeds)))
+}
+
+// MeasureFalsePositiveRate empirically measures the filter's false positive
+// rate by querying probes items, where absent(i) returns the i-th item to
+// query. The caller must ensure that none of these items have been added.
+//
+// It returns the observed false positive rate, along with the lower and upper
+// bounds of a 99.9% confidence interval for the true rate, computed using the
+// Wilson score interval. This is primarily useful for validating that
+// [Filter.EstimatedFalsePositiveRate] matches the filter's real behaviour.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) MeasureFalsePositiveRate(probes int, absent func(i int) T) (observed, lower, upper float64) {
+	if probes <= 0 {
+		return 0, 0, 1
+	}
+
+	var positives int
+	for i := range probes {
+		if bf.contains(absent(i)) {
+			positives++
+		}
+	}
+
+	// See: https://en.wikipedia.org/wiki/Binomial_proportion_confidence_interval#Wilson_score_interval
+	const z = 3.2905 // 99.9% two-sided
+	n := float64(probes)
+	observed = float64(positives) / n
+
+	denom := 1 + z*z/n
+	center := (observed + z*z/(2*n)) / denom
+	halfWidth := z / denom * math.Sqrt(observed*(1-observed)/n+z*z/(4*n*n))
+	lower = max(center-halfWidth, 0)
+	upper = min(center+halfWidth, 1)
+	return observed, lower, upper
+}
+
+// FalsePositiveRate returns the expected false positive rate of a Bloom filter
+// with an m-bit array and k hash functions after n items have been added.
+func FalsePositiveRate(m, k, n uint) float64 {
+	if n == 0 {
 		return 0
 	}
+	if m == 0 {
+		return 1
+	}
+	// With no hash functions every item matches, as (1 - e^0)^0 = 1.
+	if k == 0 {
+		return 1
+	}
 
 	// From Wikipedia: https://en.wikipedia.org/wiki/Bloom_filter#Probability_of_false_positives
 	//
@@ -110,23 +1007,50 @@ func (bf *Filter[T]) EstimatedFalsePositiveRate() float64 {
 	//     this occurs for all k bits is:
 	//     (1 - e^(-kn/m))^k
 
-	k := float64(len(bf.seeds))
-	n := float64(bf.entries)
-	m := float64(bf.m)
+	kf := float64(k)
+	nf := float64(n)
+	mf := float64(m)
+
+	// Computing 1 - e^(-kn/m) directly loses most of its precision when
+	// kn/m is small, which is where the rate is tiny; Expm1 avoids the
+	// cancellation. Raising the result to the k-th power in log space keeps
+	// the rate meaningful until it underflows float64 entirely.
+	probBitIsOne := -math.Expm1(-kf * nf / mf)
+	if probBitIsOne == 0 {
+		return 0
+	}
+	return math.Exp(kf * math.Log(probBitIsOne))
+}
+
+// validBitCount returns the number of bits set in the filter. Only the first
+// m bits are counted: the final word of bits can have bits beyond m set, such
+// as by decoding corrupt data, which must not affect the filter's statistics.
+func (bf *Filter[T]) validBitCount() uint {
+	var n int
+	for _, word := range bf.bits[:len(bf.bits)-1] {
+		n += bits.OnesCount64(word)
+	}
+	n += bits.OnesCount64(bf.bits[len(bf.bits)-1] & bf.tailMask())
+	return uint(n)
+}
 
-	probBitIsZero := math.Exp(-k * n / m)
-	probBitIsOne := 1 - probBitIsZero
-	return math.Pow(probBitIsOne, k)
+// tailMask returns a mask of the bits of the final word of bits that are
+// within the first m bits.
+func (bf *Filter[T]) tailMask() uint64 {
+	if r := bf.m % 64; r != 0 {
+		return 1<<r - 1
+	}
+	return math.MaxUint64
 }
 
 func bloomParams(expectedItems uint, falsePositiveRate float64) (bitsNeeded uint, numHashFunctions uint) {
 	// Use the standard naming from Wikipedia to make the equations easier to follow
-	n := float64(expectedItems)
-	p := falsePositiveRate
+	n := float64(max(expectedItems, 1))
+	p := checkFalsePositiveRate(falsePositiveRate)
 
 	// Calculate the number of bits we need in our bit array
 	m := -n * math.Log(p) / math.Pow(math.Log(2), 2)
-	bitsNeeded = uint(math.Ceil(m))
+	bitsNeeded = checkBits(m)
 
 	// Calculate the number of hash functions we need
 	k := m / n * math.Log(2)
@@ -136,3 +1060,25 @@ func bloomParams(expectedItems uint, falsePositiveRate float64) (bitsNeeded uint
 	numHashFunctions = max(numHashFunctions, 1)
 	return
 }
+
+// checkFalsePositiveRate validates a false positive rate, clamping it to at
+// most one. It rejects inputs that would otherwise produce NaN or infinite
+// filter sizes, which convert to meaningless uint values.
+func checkFalsePositiveRate(p float64) float64 {
+	if math.IsNaN(p) || p <= 0 {
+		panic("bloom: false positive rate must be greater than zero")
+	}
+	return min(p, 1)
+}
+
+// checkBits converts a computed number of bits to a uint, rounding up, and
+// clamping to at least one bit.
+func checkBits(m float64) uint {
+	if math.IsInf(m, 0) || m > float64(math.MaxInt-63) {
+		panic("bloom: filter size overflows")
+	}
+	if !(m >= 1) {
+		return 1
+	}
+	return uint(math.Ceil(m))
+}
@@ -1,7 +1,12 @@
 package bloom
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"hash/maphash"
+	"math"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -22,11 +27,90 @@ func TestBloomFilter(t *testing.T) {
 	if !bf.Contains("banana") {
 		t.Error("'banana' should be in the filter")
 	}
+}
 
-	// TODO(andrew-d): over enough test runs, we could get the occasional
-	// false positive; we should just retry the test in that case.
-	if bf.Contains("grape") {
-		t.Error("'grape' should not be in the filter")
+func TestBloomFilter_ActualFalsePositiveRate(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	if fpr := bf.ActualFalsePositiveRate(); fpr != 0 {
+		t.Errorf("got %v, want 0", fpr)
+	}
+
+	for i := range 1000 {
+		bf.Add(i)
+	}
+	actual := bf.ActualFalsePositiveRate()
+	if estimated := bf.EstimatedFalsePositiveRate(); math.Abs(actual-estimated) > estimated/4 {
+		t.Errorf("got actual FPR %v, want approximately %v", actual, estimated)
+	}
+
+	// Re-adding items shouldn't change the actual rate.
+	for i := range 1000 {
+		bf.Add(i)
+	}
+	if got := bf.ActualFalsePositiveRate(); got != actual {
+		t.Errorf("got %v after re-adding items, want %v", got, actual)
+	}
+}
+
+func TestBloomFilter_MeasureFalsePositiveRate(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	for i := range 1000 {
+		bf.Add(i)
+	}
+
+	// Rather than asserting that a single absent item isn't reported as
+	// present, which fails occasionally, check that the observed false
+	// positive rate is consistent with the estimate. The true rate of any
+	// single filter varies around the estimate depending on how many bits
+	// happened to collide, so we allow some slack.
+	observed, lower, upper := bf.MeasureFalsePositiveRate(100000, func(i int) int {
+		return 1000 + i
+	})
+	if observed < lower || observed > upper {
+		t.Errorf("observed rate %v outside its own interval [%v, %v]", observed, lower, upper)
+	}
+	if estimated := bf.EstimatedFalsePositiveRate(); estimated < lower*0.75 || estimated > upper*1.25 {
+		t.Errorf("estimated rate %v too far from observed interval [%v, %v]", estimated, lower, upper)
+	}
+}
+
+func TestBloomFilter_NamedTypes(t *testing.T) {
+	type Status int
+	const (
+		StatusActive Status = iota + 1
+		StatusInactive
+	)
+
+	statuses := NewBloomFilter[Status](1000, 0.01)
+	statuses.Add(StatusActive)
+	if !statuses.Contains(StatusActive) || !statuses.Contains(Status(1)) {
+		t.Error("StatusActive should be in the filter")
+	}
+	if slices.Equal(statuses.HashPositions(StatusActive), statuses.HashPositions(StatusInactive)) {
+		t.Error("different Status values should hash differently")
+	}
+
+	// A defined type hashes the same as its underlying type...
+	ints, err := NewBloomFilterWithSeeds[int](statuses.m, uint(len(statuses.seeds)), statuses.Seeds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(statuses.HashPositions(StatusActive), ints.HashPositions(1)) {
+		t.Error("Status(1) should hash the same as int(1)")
+	}
+
+	// ... even when hashed as an interface value, although Status(1) !=
+	// any(1). This is a documented source of false positives.
+	anys, err := NewBloomFilterWithSeeds[any](statuses.m, uint(len(statuses.seeds)), statuses.Seeds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	anys.Add(StatusActive)
+	if !anys.Contains(StatusActive) {
+		t.Error("StatusActive should be in the filter")
+	}
+	if !anys.Contains(1) {
+		t.Error("any(1) should be reported as present after adding any(Status(1))")
 	}
 }
 
@@ -77,6 +161,822 @@ func TestBloomFilter_EstimatedFalsePositiveRate(t *testing.T) {
 	}
 }
 
+func TestBloomFilter_Clone(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01, WithFNVHash())
+	bf.Add(1)
+
+	clone := bf.Clone()
+	if !clone.Contains(1) || clone.entries != bf.entries {
+		t.Errorf("clone should contain 1 with %d entries, got %d", bf.entries, clone.entries)
+	}
+
+	clone.Add(2)
+	if bf.Contains(2) || bf.entries != 1 {
+		t.Error("adding to the clone shouldn't change the original")
+	}
+}
+
+func TestBloomFilter_Clone_Options(t *testing.T) {
+	var adds int
+	bf := NewBloomFilter[int](1000, 0.01,
+		WithoutEntryCounting(),
+		WithFNVHash(),
+		WithDomain("test"),
+		WithHooks(Hooks{OnAdd: func() { adds++ }}),
+		WithSaturationCheck(100),
+		WithIntervalStats(),
+	)
+	bf.Add(1)
+
+	clone := bf.Clone()
+	if !clone.noEntries || !clone.fnv || !slices.Equal(clone.seeds, bf.seeds) {
+		t.Error("clone should keep the original's entry counting, hash and seeds")
+	}
+	if clone.saturation == nil || clone.saturation.interval != 100 || clone.saturation.calls.Load() != 1 {
+		t.Error("clone should keep the original's saturation check")
+	}
+	clone.Add(2)
+	if adds != 2 {
+		t.Errorf("got %d calls to OnAdd, want 2", adds)
+	}
+	if got := clone.SnapshotStats().Adds; got != 2 {
+		t.Errorf("got %d adds counted by the clone, want 2", got)
+	}
+	if got := bf.SnapshotStats().Adds; got != 1 {
+		t.Errorf("got %d adds counted by the original, want 1", got)
+	}
+}
+
+func TestBloomFilter_Seeds(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01)
+	bf.Add("apple")
+
+	seeds := bf.Seeds()
+	restored, err := NewBloomFilterWithSeeds[string](bf.m, uint(len(seeds)), seeds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored.Add("apple")
+
+	if !slices.Equal(bf.bits, restored.bits) {
+		t.Error("filters with the same seeds should have identical bits")
+	}
+
+	// Modifying the returned seeds must not affect the filter.
+	seeds[0]++
+	if slices.Equal(seeds, bf.Seeds()) {
+		t.Error("Seeds should return a copy")
+	}
+
+	if _, err := NewBloomFilterWithSeeds[string](bf.m, uint(len(seeds))+1, seeds); err == nil {
+		t.Error("expected error for mismatched number of seeds")
+	}
+}
+
+func TestNewLike(t *testing.T) {
+	template := NewBloomFilter[int](1000, 0.01, WithFNVHash())
+	template.Add(1)
+
+	bf := NewLike(template)
+	if bf.entries != 0 || bf.validBitCount() != 0 {
+		t.Errorf("got %d entries and %d bits set, want an empty filter", bf.entries, bf.validBitCount())
+	}
+	if bf.Contains(1) {
+		t.Error("new filter shouldn't contain items added to the template")
+	}
+
+	bf.Add(2)
+	if err := template.Union(bf); err != nil {
+		t.Fatalf("Union: %v", err)
+	}
+	for _, item := range []int{1, 2} {
+		if !template.Contains(item) {
+			t.Errorf("union should contain %d", item)
+		}
+	}
+}
+
+func TestNewLike_Options(t *testing.T) {
+	var adds int
+	template := NewBloomFilter[int](1000, 0.01,
+		WithoutEntryCounting(),
+		WithFNVHash(),
+		WithDomain("test"),
+		WithHooks(Hooks{OnAdd: func() { adds++ }}),
+		WithSaturationCheck(100),
+		WithIntervalStats(),
+	)
+
+	bf := NewLike(template)
+	if !bf.noEntries || !bf.fnv || !slices.Equal(bf.seeds, template.seeds) {
+		t.Error("new filter should keep the template's entry counting, hash and seeds")
+	}
+	if bf.saturation == nil || bf.saturation.interval != 100 {
+		t.Error("new filter should keep the template's saturation check")
+	}
+	if bf.interval == nil || bf.interval == template.interval {
+		t.Error("new filter should have its own interval stats")
+	}
+	bf.Add(1)
+	if adds != 1 {
+		t.Errorf("got %d calls to OnAdd, want 1", adds)
+	}
+	if got := template.SnapshotStats().Adds; got != 0 {
+		t.Errorf("got %d adds counted by the template, want 0", got)
+	}
+
+	if !NewLike(NewDistinctFilter[int](1000, 0.01)).distinct {
+		t.Error("new filter should keep the template's distinct counting")
+	}
+}
+
+func TestNewFromSeq(t *testing.T) {
+	fruits := []string{"apple", "banana", "orange"}
+	bf := NewFromSeq(slices.Values(fruits), 1000, 0.01)
+
+	for _, fruit := range fruits {
+		if !bf.Contains(fruit) {
+			t.Errorf("'%s' should be in the filter", fruit)
+		}
+	}
+	if got, want := bf.entries, uint(len(fruits)); got != want {
+		t.Errorf("got entries=%d, want %d", got, want)
+	}
+}
+
+func TestNewBloomFilterForLoad(t *testing.T) {
+	const (
+		expectedItems = 10000
+		expectedLoad  = 1000
+		targetFPR     = 0.01
+	)
+	full := NewBloomFilter[int](expectedItems, targetFPR)
+	bf := NewBloomFilterForLoad[int](expectedItems, targetFPR, expectedLoad)
+
+	if bf.m != full.m {
+		t.Errorf("got m=%d, want %d", bf.m, full.m)
+	}
+	if len(bf.seeds) >= len(full.seeds) {
+		t.Errorf("got k=%d, want fewer than %d", len(bf.seeds), len(full.seeds))
+	}
+
+	for i := range expectedLoad {
+		bf.Add(i)
+	}
+	if fpr := bf.EstimatedFalsePositiveRate(); fpr > targetFPR {
+		t.Errorf("got estimated FPR %v at expected load, want <= %v", fpr, targetFPR)
+	}
+}
+
+func TestNewBloomFilterFixedK(t *testing.T) {
+	for _, k := range []uint{1, 4, 20} {
+		bf := NewBloomFilterFixedK[int](1000, 0.01, k)
+		if got := uint(len(bf.seeds)); got != k {
+			t.Errorf("got k=%d, want %d", got, k)
+		}
+
+		// The expected FPR at capacity should match the target.
+		if fpr := FalsePositiveRate(bf.m, k, 1000); math.Abs(fpr-0.01) > 0.0001 {
+			t.Errorf("k=%d: got expected FPR %v at capacity, want 0.01", k, fpr)
+		}
+	}
+
+	// The optimal k should use about the same number of bits as the
+	// standard constructor.
+	optimal := NewBloomFilter[int](1000, 0.01)
+	fixed := NewBloomFilterFixedK[int](1000, 0.01, uint(len(optimal.seeds)))
+	if diff := math.Abs(float64(fixed.m) - float64(optimal.m)); diff > float64(optimal.m)/50 {
+		t.Errorf("got m=%d, want approximately %d", fixed.m, optimal.m)
+	}
+}
+
+func TestNewBloomFilterPow2(t *testing.T) {
+	for _, n := range []uint{1, 100, 1000, 12345} {
+		bf := NewBloomFilterPow2[int](n, 0.01)
+		if bf.m&(bf.m-1) != 0 {
+			t.Errorf("n=%d: got m=%d, want a power of two", n, bf.m)
+		}
+		if want, _ := bloomParams(n, 0.01); bf.m < want {
+			t.Errorf("n=%d: got m=%d, want >= %d", n, bf.m, want)
+		}
+		if fpr := FalsePositiveRate(bf.m, uint(len(bf.seeds)), n); fpr > 0.01 {
+			t.Errorf("n=%d: got expected FPR %v at capacity, want <= 0.01", n, fpr)
+		}
+	}
+}
+
+func TestNewBloomFilterBitsPerElement(t *testing.T) {
+	bf := NewBloomFilterBitsPerElement[int](1000, 10)
+	if got, want := bf.m, uint(10000); got != want {
+		t.Errorf("got m=%d, want %d", got, want)
+	}
+	if got, want := len(bf.seeds), 7; got != want {
+		t.Errorf("got k=%d, want %d", got, want)
+	}
+	if fpr := bf.TargetFalsePositiveRate(); fpr < 0.005 || fpr > 0.01 {
+		t.Errorf("got target FPR %v, want about 0.008", fpr)
+	}
+
+	// A low ratio still uses at least one hash function.
+	if bf := NewBloomFilterBitsPerElement[int](1000, 0.5); len(bf.seeds) != 1 {
+		t.Errorf("got k=%d, want 1", len(bf.seeds))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for zero bits per element")
+		}
+	}()
+	NewBloomFilterBitsPerElement[int](1000, 0)
+}
+
+func TestNewBloomFilterInRange(t *testing.T) {
+	for _, r := range [][2]float64{{1e-3, 1e-2}, {1e-6, 1e-5}, {0.1, 0.5}} {
+		bf := NewBloomFilterInRange[int](10000, r[0], r[1])
+		got := bf.TargetFalsePositiveRate()
+		if got < r[0] || got > r[1] {
+			t.Errorf("range %v: got false positive rate %v", r, got)
+		}
+		if want := FalsePositiveRate(bf.m, uint(len(bf.seeds)), 10000); got != want {
+			t.Errorf("range %v: got target %v, want %v", r, got, want)
+		}
+
+		// The filter should be essentially as small as one sized for
+		// the top of the range.
+		if ref := NewBloomFilter[int](10000, r[1]); float64(bf.m) > float64(ref.m)*1.01 {
+			t.Errorf("range %v: got %d bits, want about %d", r, bf.m, ref.m)
+		}
+	}
+
+	// With few items, each bit changes the rate considerably, so the
+	// nearest filter for the top of the range can fall below it.
+	for _, n := range []uint{1, 2, 3, 10} {
+		bf := NewBloomFilterInRange[int](n, 0.0099, 0.01)
+		if got := bf.TargetFalsePositiveRate(); got < 0.0099 || got > 0.01 {
+			t.Errorf("n=%d: got false positive rate %v, want in [0.0099, 0.01]", n, got)
+		}
+	}
+
+	for _, r := range [][2]float64{{0, 0.1}, {0.1, 0.1}, {0.2, 0.1}, {0.1, 1}, {math.NaN(), 0.1}, {0.5, 0.5000001}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("range %v: expected panic", r)
+				}
+			}()
+			NewBloomFilterInRange[int](10000, r[0], r[1])
+		}()
+	}
+}
+
+func TestBloomFilter_TargetFalsePositiveRate(t *testing.T) {
+	if got := NewBloomFilter[int](1000, 0.01).TargetFalsePositiveRate(); got != 0.01 {
+		t.Errorf("got target FPR %v, want 0.01", got)
+	}
+	bf, err := NewBloomFilterWithSeeds[int](1000, 1, []uint64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := bf.TargetFalsePositiveRate(); got != 0 {
+		t.Errorf("got target FPR %v for unknown sizing, want 0", got)
+	}
+}
+
+func TestBloomFilter_DefinitelyAbsent(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01, WithFNVHash())
+	bf.Add("apple")
+	if bf.DefinitelyAbsent("apple") {
+		t.Error("'apple' was added, so it isn't definitely absent")
+	}
+	if !bf.DefinitelyAbsent("banana") {
+		t.Error("'banana' should be definitely absent")
+	}
+}
+
+func TestBloomFilter_ContainsProbability(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01, WithFNVHash())
+	for i := range 1000 {
+		bf.Add(i)
+	}
+
+	if got, want := bf.ContainsProbability(1), 1-bf.ActualFalsePositiveRate(); got != want {
+		t.Errorf("got probability %v for a present item, want %v", got, want)
+	}
+	if got := bf.ContainsProbability(1); got < 0.98 || got >= 1 {
+		t.Errorf("got probability %v, want about 0.99", got)
+	}
+	for i := 1000; i < 2000; i++ {
+		if !bf.Contains(i) {
+			if got := bf.ContainsProbability(i); got != 0 {
+				t.Errorf("got probability %v for an absent item, want 0", got)
+			}
+			break
+		}
+	}
+}
+
+func TestBloomFilter_Sum(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01)
+	seed := maphash.MakeSeed()
+
+	for _, fruit := range []string{"apple", "banana", "orange"} {
+		bf.AddSum(maphash.String(seed, fruit))
+	}
+
+	if !bf.ContainsSum(maphash.String(seed, "apple")) {
+		t.Error("'apple' should be in the filter")
+	}
+	if !bf.ContainsSum(maphash.String(seed, "banana")) {
+		t.Error("'banana' should be in the filter")
+	}
+	if bf.ContainsSum(maphash.String(seed, "grape")) {
+		t.Error("'grape' should not be in the filter")
+	}
+	if got, want := bf.entries, uint(3); got != want {
+		t.Errorf("got entries=%d, want %d", got, want)
+	}
+}
+
+func TestBloomFilter_AddSumOptions(t *testing.T) {
+	var adds int
+	bf := NewDistinctFilter[string](1000, 0.01,
+		WithHooks(Hooks{OnAdd: func() { adds++ }}),
+		WithSaturationCheck(100),
+		WithIntervalStats(),
+	)
+	bf.AddSum(1)
+	bf.AddSum(1)
+
+	if adds != 2 {
+		t.Errorf("got %d calls to OnAdd, want 2", adds)
+	}
+	if got := bf.saturation.calls.Load(); got != 2 {
+		t.Errorf("got %d saturation check calls, want 2", got)
+	}
+	if got := bf.SnapshotStats().Adds; got != 2 {
+		t.Errorf("got %d adds, want 2", got)
+	}
+	if got, want := bf.entries, uint(1); got != want {
+		t.Errorf("got entries=%d, want %d for a repeated sum", got, want)
+	}
+}
+
+func TestBloomFilter_ContainsSumOptions(t *testing.T) {
+	var results []bool
+	bf := NewBloomFilter[string](1000, 0.01,
+		WithHooks(Hooks{OnContains: func(found bool) { results = append(results, found) }}),
+		WithSaturationCheck(100),
+		WithIntervalStats(),
+	)
+	bf.AddSum(1)
+	bf.ContainsSum(1)
+
+	if !slices.Equal(results, []bool{true}) {
+		t.Errorf("got OnContains calls %v, want [true]", results)
+	}
+	if got := bf.saturation.calls.Load(); got != 2 {
+		t.Errorf("got %d saturation check calls, want 2", got)
+	}
+	if got, want := bf.SnapshotStats(), (IntervalStats{Adds: 1, Contains: 1, Positives: 1}); got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+}
+
+func TestBloomFilter_RegenerateSeeds(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01, WithFNVHash())
+	for i := range 1000 {
+		bf.Add(i)
+	}
+	seeds, m := bf.Seeds(), bf.m
+
+	bf.RegenerateSeeds()
+	if slices.Equal(bf.Seeds(), seeds) || len(bf.seeds) != len(seeds) || bf.m != m {
+		t.Error("RegenerateSeeds should replace the seeds, keeping m and k")
+	}
+	if bf.entries != 0 || bf.validBitCount() != 0 {
+		t.Errorf("got %d entries and %d bits set, want an empty filter", bf.entries, bf.validBitCount())
+	}
+
+	bf.Add(1)
+	data, err := bf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Filter[int]
+	if err := got.UnmarshalBinary(data); err != nil || !got.Contains(1) {
+		t.Errorf("got error %v, want a decoded filter containing 1", err)
+	}
+}
+
+func TestBloomFilter_AddUnique(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01)
+	bf.AddUnique([]string{"apple", "banana", "apple", "orange", "banana", "apple"})
+
+	if got, want := bf.entries, uint(3); got != want {
+		t.Errorf("got entries=%d, want %d", got, want)
+	}
+	for _, fruit := range []string{"apple", "banana", "orange"} {
+		if !bf.Contains(fruit) {
+			t.Errorf("'%s' should be in the filter", fruit)
+		}
+	}
+}
+
+func TestBloomFilter_AddBatchContext(t *testing.T) {
+	items := make([]int, 10000)
+	for i := range items {
+		items[i] = i
+	}
+
+	bf := NewBloomFilter[int](10000, 0.01)
+	if err := bf.AddBatchContext(context.Background(), items); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bf.entries, uint(len(items)); got != want {
+		t.Errorf("got entries=%d, want %d", got, want)
+	}
+	for _, item := range items {
+		if !bf.Contains(item) {
+			t.Fatalf("%d should be in the filter", item)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bf = NewBloomFilter[int](10000, 0.01)
+	if err := bf.AddBatchContext(ctx, items); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if bf.entries != 0 {
+		t.Errorf("got entries=%d, want 0", bf.entries)
+	}
+}
+
+func TestBloomFilter_HashPositions(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01)
+	positions := bf.HashPositions("apple")
+	if got, want := len(positions), len(bf.seeds); got != want {
+		t.Fatalf("got %d positions, want %d", got, want)
+	}
+
+	bf.Add("apple")
+	for _, pos := range positions {
+		if pos >= bf.m {
+			t.Errorf("position %d out of range [0, %d)", pos, bf.m)
+		}
+		if bf.bits[pos/64]&(1<<(pos%64)) == 0 {
+			t.Errorf("bit %d should be set after adding 'apple'", pos)
+		}
+	}
+
+	// Exactly those bits should be set; positions may collide, so count
+	// unique ones.
+	slices.Sort(positions)
+	setBits := uint(len(slices.Compact(positions)))
+	if got := bf.validBitCount(); got != setBits {
+		t.Errorf("got %d set bits, want %d", got, setBits)
+	}
+}
+
+func TestBloomFilter_RawHashes(t *testing.T) {
+	const item = 12345
+	bf := NewBloomFilter[int](1000, 0.01, WithFNVHash())
+	hashes := bf.RawHashes(item)
+	positions := bf.HashPositions(item)
+	if got, want := len(hashes), len(bf.seeds); got != want {
+		t.Fatalf("got %d hashes, want %d", got, want)
+	}
+
+	for i, hash := range hashes {
+		if got, want := uint(reduce(hash, bf.m)), positions[i]; got != want {
+			t.Errorf("hash %d reduces to %d, want position %d", i, got, want)
+		}
+
+		// An independent FNV-1a of the item's canonical encoding, eight
+		// little-endian bytes, with the documented offset and finalizer.
+		h := uint64(14695981039346656037) ^ bf.seeds[i]
+		for b := range 8 {
+			h ^= uint64(item) >> (8 * b) & 0xff
+			h *= 1099511628211
+		}
+		if want := mix64(h); hash != want {
+			t.Errorf("hash %d: got %#x, want %#x", i, hash, want)
+		}
+	}
+}
+
+func TestBloomFilter_ConfigHash(t *testing.T) {
+	a := NewBloomFilter[string](1000, 0.01)
+	same, err := NewBloomFilterWithSeeds[string](a.m, uint(len(a.seeds)), a.Seeds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	same.Add("apple")
+	if a.ConfigHash() != same.ConfigHash() {
+		t.Error("filters with the same configuration should have equal ConfigHash")
+	}
+
+	b := NewBloomFilter[string](1000, 0.01)
+	if a.ConfigHash() == b.ConfigHash() {
+		t.Error("filters with different seeds should have different ConfigHash")
+	}
+
+	bigger, err := NewBloomFilterWithSeeds[string](a.m+1, uint(len(a.seeds)), a.Seeds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ConfigHash() == bigger.ConfigHash() {
+		t.Error("filters with different sizes should have different ConfigHash")
+	}
+	fnvHashed, err := NewBloomFilterWithSeeds[string](a.m, uint(len(a.seeds)), a.Seeds(), WithFNVHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ConfigHash() == fnvHashed.ConfigHash() {
+		t.Error("filters with different hashing schemes should have different ConfigHash")
+	}
+}
+
+func TestBloomFilter_Bits(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01)
+	bf.Add("apple")
+
+	if got, want := bf.BitsLen(), bf.m; got != want {
+		t.Errorf("got BitsLen %d, want %d", got, want)
+	}
+
+	positions := bf.HashPositions("apple")
+	words := bf.Bits()
+	var set int
+	for i := range bf.BitsLen() {
+		fromWords := words[i/64]&(1<<(i%64)) != 0
+		if bf.BitAt(i) != fromWords {
+			t.Fatalf("BitAt(%d) disagrees with Bits", i)
+		}
+		if fromWords {
+			set++
+			if !slices.Contains(positions, i) {
+				t.Errorf("bit %d is set but is not a position of 'apple'", i)
+			}
+		}
+	}
+	if set == 0 {
+		t.Error("expected some bits to be set")
+	}
+
+	// Modifying the returned words must not affect the filter.
+	words[0] = ^words[0]
+	if slices.Equal(words, bf.bits) {
+		t.Error("Bits should return a copy")
+	}
+}
+
+func TestBloomFilter_Words(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	for i := range 100 {
+		bf.Add(i)
+	}
+	bf.bits[len(bf.bits)-1] |= ^bf.tailMask() // bits beyond m
+
+	var words []uint64
+	for i, word := range bf.Words() {
+		if i != len(words) {
+			t.Fatalf("got index %d, want %d", i, len(words))
+		}
+		words = append(words, word)
+	}
+	if !slices.Equal(words, bf.Bits()) {
+		t.Error("Words should yield the same words as Bits")
+	}
+
+	// Stopping early is respected.
+	var n int
+	for range bf.Words() {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("got %d words after break, want 1", n)
+	}
+}
+
+func TestBloomFilter_AddReturningNewBits(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01, WithFNVHash())
+
+	positions, newBits := bf.addReturningNewBits("apple")
+	if want := []uint{8005, 5519, 2312, 67, 6710, 5511, 7702}; !slices.Equal(positions, want) {
+		t.Errorf("got positions %v, want %v", positions, want)
+	}
+	if newBits != len(positions) {
+		t.Errorf("got %d new bits, want %d", newBits, len(positions))
+	}
+	if !slices.Equal(positions, bf.HashPositions("apple")) {
+		t.Error("positions should match HashPositions")
+	}
+
+	// Adding the same item again sets no new bits, but still counts.
+	if _, newBits := bf.addReturningNewBits("apple"); newBits != 0 {
+		t.Errorf("got %d new bits on re-add, want 0", newBits)
+	}
+	if bf.entries != 2 {
+		t.Errorf("got %d entries, want 2", bf.entries)
+	}
+	if got, want := bf.validBitCount(), uint(len(positions)); got != want {
+		t.Errorf("got %d set bits, want %d", got, want)
+	}
+
+	// It goes through the same bookkeeping as Add.
+	var adds int
+	bf = NewDistinctFilter[string](1000, 0.01, WithHooks(Hooks{OnAdd: func() { adds++ }}), WithIntervalStats())
+	bf.addReturningNewBits("apple")
+	bf.addReturningNewBits("apple")
+	if adds != 2 || bf.SnapshotStats().Adds != 2 {
+		t.Errorf("got %d calls to OnAdd, want 2 counted by hooks and stats", adds)
+	}
+	if bf.entries != 1 {
+		t.Errorf("got %d entries for a distinct filter, want 1", bf.entries)
+	}
+}
+
+func TestBloomFilter_CountPresent(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	for i := range 1000 {
+		bf.Add(i)
+	}
+
+	// Query 500 items that are present and 10000 that aren't.
+	var items []int
+	for i := 500; i < 11000; i++ {
+		items = append(items, i)
+	}
+
+	present, estimated := bf.CountPresent(items)
+	if present < 500 {
+		t.Errorf("got present=%d, want >= 500", present)
+	}
+	if estimated > float64(present) {
+		t.Errorf("estimated true positives %v exceeds present count %d", estimated, present)
+	}
+	if math.Abs(estimated-500) > 50 {
+		t.Errorf("got estimated true positives %v, want approximately 500", estimated)
+	}
+}
+
+func TestBloomFilter_ContainsMany(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	for i := 0; i < 200; i += 2 {
+		bf.Add(i)
+	}
+
+	items := make([]int, 200)
+	for i := range items {
+		items[i] = i
+	}
+
+	result := bf.ContainsMany(items)
+	if got, want := len(result), 4; got != want {
+		t.Fatalf("got len(result)=%d, want %d", got, want)
+	}
+	for i, item := range items {
+		if got, want := ResultAt(result, i), bf.Contains(item); got != want {
+			t.Errorf("ResultAt(%d) = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestBloomParams_EdgeCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		n         uint
+		p         float64
+		wantPanic bool
+	}{
+		{"zero items", 0, 0.01, false},
+		{"fpr of one", 1000, 1, false},
+		{"fpr above one", 1000, 2, false},
+		{"positive infinity", 1000, math.Inf(1), false},
+		{"tiny fpr", 1000, math.SmallestNonzeroFloat64, false},
+		{"zero fpr", 1000, 0, true},
+		{"negative fpr", 1000, -0.5, true},
+		{"negative infinity", 1000, math.Inf(-1), true},
+		{"NaN", 1000, math.NaN(), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if gotPanic := r != nil; gotPanic != tt.wantPanic {
+					t.Errorf("got panic=%v (%v), want panic=%v", gotPanic, r, tt.wantPanic)
+				}
+			}()
+
+			m, k := bloomParams(tt.n, tt.p)
+			if m < 1 || k < 1 {
+				t.Errorf("got m=%d, k=%d; want both >= 1", m, k)
+			}
+
+			// The resulting filter should be usable.
+			bf := NewBloomFilter[int](tt.n, tt.p)
+			bf.Add(1)
+			if !bf.Contains(1) {
+				t.Error("1 should be in the filter")
+			}
+		})
+	}
+}
+
+func TestFalsePositiveRate(t *testing.T) {
+	tests := []struct {
+		m, k, n uint
+		want    float64
+	}{
+		{m: 1000, k: 5, n: 0, want: 0},
+		{m: 0, k: 5, n: 10, want: 1},
+		{m: 1000, k: 0, n: 10, want: 1},
+		// (1 - e^(-1))^1
+		{m: 1000, k: 1, n: 1000, want: 1 - math.Exp(-1)},
+		// (1 - e^(-0.5))^2
+		{m: 1000, k: 2, n: 250, want: math.Pow(1-math.Exp(-0.5), 2)},
+	}
+	for _, tt := range tests {
+		if got := FalsePositiveRate(tt.m, tt.k, tt.n); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("FalsePositiveRate(%d, %d, %d) = %v, want %v", tt.m, tt.k, tt.n, got, tt.want)
+		}
+	}
+
+	// Very small rates should keep their precision: for small x = kn/m,
+	// 1 - e^(-x) = x - x^2/2 + O(x^3). m must fit in a 32-bit uint.
+	const m, k = 1e9, 20
+	x := float64(k) / m
+	if got, want := FalsePositiveRate(m, k, 1), math.Pow(x-x*x/2, k); math.Abs(got-want) > want*1e-9 {
+		t.Errorf("FalsePositiveRate(%d, %d, 1) = %v, want %v", uint(m), k, got, want)
+	}
+
+	// The smallest possible ratio kn/m should still give a finite rate.
+	if got, want := FalsePositiveRate(math.MaxUint, 1, 1), 1/float64(math.MaxUint); math.Abs(got-want) > want*1e-9 {
+		t.Errorf("FalsePositiveRate(MaxUint, 1, 1) = %v, want %v", got, want)
+	}
+
+	// The filter's estimate should use the same formula.
+	bf := NewBloomFilter[int](1000, 0.01)
+	for i := range 500 {
+		bf.Add(i)
+	}
+	if got, want := bf.EstimatedFalsePositiveRate(), FalsePositiveRate(bf.m, uint(len(bf.seeds)), 500); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFalsePositiveRate_Tiny(t *testing.T) {
+	for _, p := range []float64{1e-9, 1e-12} {
+		const n = 10000
+		m, k := bloomParams(n, p)
+
+		// At capacity, the rate should be close to the target; rounding k
+		// up can leave it slightly above.
+		if got := FalsePositiveRate(m, k, n); got < p/2 || got > p*1.01 {
+			t.Errorf("p=%v: FalsePositiveRate at capacity = %v", p, got)
+		}
+
+		// Below capacity, the rate should fall steadily, rather than
+		// reaching zero early.
+		prev := FalsePositiveRate(m, k, n)
+		for items := uint(n / 2); items > 0; items /= 2 {
+			got := FalsePositiveRate(m, k, items)
+			if got <= 0 || got >= prev {
+				t.Errorf("p=%v: FalsePositiveRate with %d items = %v, want in (0, %v)", p, items, got, prev)
+			}
+			prev = got
+		}
+	}
+}
+
+func TestBloomFilter_WithoutEntryCounting(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01, WithoutEntryCounting())
+	if fpr := bf.EstimatedFalsePositiveRate(); fpr != 0 {
+		t.Errorf("got %v, want 0", fpr)
+	}
+
+	for i := range 1000 {
+		bf.Add(i)
+	}
+	if bf.entries != 0 {
+		t.Errorf("got entries=%d, want 0", bf.entries)
+	}
+
+	// Adding duplicates shouldn't change the bit-based estimate.
+	want := bf.EstimatedFalsePositiveRate()
+	for i := range 1000 {
+		bf.Add(i)
+	}
+	if got := bf.EstimatedFalsePositiveRate(); got != want {
+		t.Errorf("duplicates changed estimate: got %v, want %v", got, want)
+	}
+	if want > 0.02 || want < 0.005 {
+		t.Errorf("got estimate %v, want approximately 0.01", want)
+	}
+}
+
 func BenchmarkBloomFilterAdd(b *testing.B) {
 	// Test cases with different string lengths
 	lengths := []int{10, 100, 1000, 10000}
@@ -97,3 +997,291 @@ func BenchmarkBloomFilterAdd(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkBloomFilterContains(b *testing.B) {
+	bf := NewBloomFilter[int](100000, 0.01)
+	for i := range 100000 {
+		bf.Add(i)
+	}
+	benchmarkContains(b, bf)
+}
+
+func BenchmarkBloomFilterContainsPow2(b *testing.B) {
+	bf := NewBloomFilterPow2[int](100000, 0.01)
+	for i := range 100000 {
+		bf.Add(i)
+	}
+	benchmarkContains(b, bf)
+}
+
+func benchmarkContains(b *testing.B, bf *Filter[int]) {
+	b.Run("present", func(b *testing.B) {
+		b.ReportAllocs()
+		i := 0
+		for b.Loop() {
+			bf.Contains(i % 100000)
+			i++
+		}
+	})
+	b.Run("absent", func(b *testing.B) {
+		b.ReportAllocs()
+		i := 0
+		for b.Loop() {
+			bf.Contains(100000 + i)
+			i++
+		}
+	})
+}
+
+func BenchmarkBloomFilterContainsParallel(b *testing.B) {
+	bf := NewBloomFilter[int](100000, 0.01)
+	for i := range 100000 {
+		bf.Add(i)
+	}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			bf.Contains(i % 200000)
+			i++
+		}
+	})
+}
+
+func TestBloomFilter_WithDomain(t *testing.T) {
+	a := NewBloomFilter[string](1000, 0.01, WithFNVHash(), WithDomain("a"))
+	b := NewBloomFilter[string](1000, 0.01, WithFNVHash(), WithDomain("b"))
+	none := NewBloomFilter[string](1000, 0.01, WithFNVHash(), WithDomain(""))
+	for _, fruit := range []string{"apple", "banana", "orange"} {
+		a.Add(fruit)
+		b.Add(fruit)
+		none.Add(fruit)
+	}
+
+	if slices.Equal(a.HashPositions("apple"), b.HashPositions("apple")) {
+		t.Error("filters with different domains should have different positions")
+	}
+	if slices.Equal(a.bits, b.bits) {
+		t.Error("filters with different domains should have different bits")
+	}
+	if a.ConfigHash() == b.ConfigHash() {
+		t.Error("filters with different domains should have different ConfigHash")
+	}
+	for _, fruit := range []string{"apple", "banana", "orange"} {
+		if !a.Contains(fruit) || !b.Contains(fruit) {
+			t.Errorf("'%s' should be in both filters", fruit)
+		}
+	}
+
+	// An empty domain is the same as no domain.
+	if want := []uint{8005, 5519, 2312, 67, 6710, 5511, 7702}; !slices.Equal(none.HashPositions("apple"), want) {
+		t.Errorf("got positions %v, want %v", none.HashPositions("apple"), want)
+	}
+
+	// The domain is included in the returned seeds.
+	restored, err := NewBloomFilterWithSeeds[string](a.m, uint(len(a.seeds)), a.Seeds(), WithFNVHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(restored.HashPositions("apple"), a.HashPositions("apple")) {
+		t.Error("restoring a filter from its seeds should preserve its domain")
+	}
+}
+
+func TestBloomFilter_ZeroValues(t *testing.T) {
+	type point struct {
+		X, Y int
+		Name string
+	}
+
+	for _, opts := range [][]Option{nil, {WithFNVHash()}} {
+		testZeroValue(t, NewBloomFilter[string](100, 0.01, opts...))
+		testZeroValue(t, NewBloomFilter[int](100, 0.01, opts...))
+		testZeroValue(t, NewBloomFilter[point](100, 0.01, opts...))
+	}
+	testZeroValue(t, NewBloomFilter[*int](100, 0.01))
+	testZeroValue(t, NewBloomFilter[any](100, 0.01))
+}
+
+// testZeroValue checks that the zero value of T is handled like any other
+// item by bf, which must be empty.
+func testZeroValue[T comparable](t *testing.T, bf *Filter[T]) {
+	t.Helper()
+	var zero T
+	if bf.Contains(zero) {
+		t.Errorf("%T: zero value should not be in an empty filter", zero)
+	}
+	bf.Add(zero)
+	if !bf.Contains(zero) {
+		t.Errorf("%T: zero value should be in the filter after Add", zero)
+	}
+}
+
+func TestBloomFilter_WithHooks(t *testing.T) {
+	var adds, positives, negatives int
+	bf := NewBloomFilter[string](1000, 0.01, WithFNVHash(), WithHooks(Hooks{
+		OnAdd: func() { adds++ },
+		OnContains: func(found bool) {
+			if found {
+				positives++
+			} else {
+				negatives++
+			}
+		},
+	}))
+
+	bf.Add("apple")
+	bf.AddUnique([]string{"banana", "orange", "banana"})
+	bf.Contains("apple")
+	bf.Contains("grape")
+	bf.ContainsMany([]string{"banana", "orange"})
+	bf.MeasureFalsePositiveRate(100, func(i int) string { return fmt.Sprint(i) })
+
+	if adds != 3 {
+		t.Errorf("got %d adds, want 3", adds)
+	}
+	if positives != 3 || negatives != 1 {
+		t.Errorf("got %d positives and %d negatives, want 3 and 1", positives, negatives)
+	}
+
+	// Filters without hooks must not allocate.
+	plain := NewBloomFilter[string](1000, 0.01)
+	if allocs := testing.AllocsPerRun(100, func() { plain.Add("apple"); plain.Contains("apple") }); allocs != 0 {
+		t.Errorf("got %v allocs, want 0", allocs)
+	}
+}
+
+func TestBloomFilter_TestAndAdd(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01, WithFNVHash())
+	if bf.TestAndAdd("apple") {
+		t.Error("'apple' should not be present before it's added")
+	}
+	if !bf.TestAndAdd("apple") {
+		t.Error("'apple' should be present when added again")
+	}
+	if got, want := bf.entries, uint(2); got != want {
+		t.Errorf("got %d entries, want %d", got, want)
+	}
+}
+
+func TestNewDistinctFilter(t *testing.T) {
+	bf := NewDistinctFilter[int](1000, 0.01)
+	for i := range 3000 {
+		bf.Add(i % 1000)
+	}
+	// A few new items may be false positives, and so not counted.
+	if bf.entries > 1000 || bf.entries < 980 {
+		t.Errorf("got %d entries, want about 1000", bf.entries)
+	}
+
+	data, err := bf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Filter[int]
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	decoded.Add(0)
+	if decoded.entries != bf.entries {
+		t.Error("decoded filter should still count only distinct items")
+	}
+}
+
+func FuzzNoFalseNegatives(f *testing.F) {
+	f.Add([]byte("apple\x00banana\x00orange"), uint16(3), false)
+	f.Add([]byte(""), uint16(1), true)
+	f.Add([]byte("\x00\x00\xff"), uint16(1000), false)
+
+	f.Fuzz(func(t *testing.T, data []byte, expectedItems uint16, fnvHash bool) {
+		var opts []Option
+		if fnvHash {
+			opts = append(opts, WithFNVHash())
+		}
+		bf := NewBloomFilter[string](uint(expectedItems), 0.01, opts...)
+
+		items := strings.Split(string(data), "\x00")
+		for _, item := range items {
+			bf.Add(item)
+		}
+		for _, item := range items {
+			if !bf.Contains(item) {
+				t.Fatalf("false negative for %q", item)
+			}
+		}
+	})
+}
+
+func BenchmarkBloomFilterContainsMany(b *testing.B) {
+	// A filter much larger than the CPU's caches, so that lookups miss.
+	const n = 1 << 24
+	bf := NewBloomFilter[int](n, 0.01)
+	for i := range n {
+		bf.Add(i)
+	}
+	// Enough items that their bits don't stay cached between iterations;
+	// half present, half absent.
+	items := make([]int, 1<<16)
+	for i := range items {
+		items[i] = i * 251 % n
+		if i%2 == 1 {
+			items[i] += n
+		}
+	}
+
+	b.Run("batched", func(b *testing.B) {
+		for b.Loop() {
+			bf.ContainsMany(items)
+		}
+	})
+	b.Run("loop", func(b *testing.B) {
+		for b.Loop() {
+			result := make([]uint64, (len(items)+63)/64)
+			for i, item := range items {
+				if bf.Contains(item) {
+					result[i/64] |= 1 << (i % 64)
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkDoubleHashingFPR(b *testing.B) {
+	const (
+		n      = 100000
+		probes = 1000000
+	)
+	sumSeed := maphash.MakeSeed()
+
+	for _, p := range []float64{0.1, 0.01, 0.001, 0.0001} {
+		// Filters with identical m and k, one hashing each item k times
+		// and the other deriving k positions from a single hash.
+		seeded := NewBloomFilter[int](n, p)
+		double, err := NewBloomFilterWithSeeds[int](seeded.m, uint(len(seeded.seeds)), seeded.Seeds())
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i := range n {
+			seeded.Add(i)
+			double.AddSum(maphash.Comparable(sumSeed, i))
+		}
+
+		b.Run(fmt.Sprintf("k=%d", len(seeded.seeds)), func(b *testing.B) {
+			for b.Loop() {
+				var seededFPs, doubleFPs int
+				for i := n; i < n+probes; i++ {
+					if seeded.Contains(i) {
+						seededFPs++
+					}
+					if double.ContainsSum(maphash.Comparable(sumSeed, i)) {
+						doubleFPs++
+					}
+				}
+				b.ReportMetric(float64(seededFPs)/probes, "seeded-fpr")
+				b.ReportMetric(float64(doubleFPs)/probes, "double-fpr")
+				b.ReportMetric(FalsePositiveRate(seeded.m, uint(len(seeded.seeds)), n), "expected-fpr")
+			}
+		})
+	}
+}
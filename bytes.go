@@ -0,0 +1,15 @@
+package bloom
+
+import "unsafe"
+
+// ContainsBytes tests whether the string with the same contents as b might be
+// in bf, without allocating a string. It is equivalent to
+// bf.Contains(string(b)).
+//
+// It can be called concurrently under the same conditions as
+// [Filter.Contains].
+func ContainsBytes(bf *Filter[string], b []byte) bool {
+	// The string doesn't outlive this call, and b isn't modified while it's
+	// in use, so it's safe to avoid the copy.
+	return bf.Contains(unsafe.String(unsafe.SliceData(b), len(b)))
+}
@@ -0,0 +1,30 @@
+package bloom
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestContainsBytes(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01)
+	for i := range 500 {
+		bf.Add(strconv.Itoa(i))
+	}
+
+	for i := range 1000 {
+		s := strconv.Itoa(i)
+		if got, want := ContainsBytes(bf, []byte(s)), bf.Contains(s); got != want {
+			t.Errorf("ContainsBytes(%q) = %v, want %v", s, got, want)
+		}
+	}
+
+	bf.Add("")
+	if !ContainsBytes(bf, nil) {
+		t.Error("nil should match the empty string")
+	}
+
+	b := []byte("123")
+	if allocs := testing.AllocsPerRun(100, func() { ContainsBytes(bf, b) }); allocs != 0 {
+		t.Errorf("got %v allocs, want 0", allocs)
+	}
+}
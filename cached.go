@@ -0,0 +1,81 @@
+package bloom
+
+import (
+	"container/list"
+	"sync"
+)
+
+// CachedFilter wraps a [Filter] with a small least-recently-used cache of
+// items that were reported as present, so that repeated lookups of hot keys
+// skip hashing entirely.
+//
+// Only positive results are cached. Since adding items to a filter only ever
+// sets bits, a positive result stays correct as more items are added. The
+// cache is cleared when the underlying filter's bits are, such as by
+// [Filter.Reset], [Filter.RegenerateSeeds], [Filter.TuneK] or decoding into
+// it. Negative results are always computed by the underlying filter.
+type CachedFilter[T comparable] struct {
+	filter *Filter[T]
+	size   int
+
+	mu     sync.Mutex
+	items  map[T]*list.Element // values are T
+	lru    *list.List          // front is most recently used
+	resets uint64              // filter.resets when items were cached
+}
+
+// NewCachedFilter returns a CachedFilter that caches up to size positive
+// lookups against bf.
+func NewCachedFilter[T comparable](bf *Filter[T], size int) *CachedFilter[T] {
+	return &CachedFilter[T]{
+		filter: bf,
+		size:   max(size, 1),
+		items:  make(map[T]*list.Element, size),
+		lru:    list.New(),
+		resets: bf.resets,
+	}
+}
+
+// Add inserts an item into the underlying filter.
+//
+// This method is not safe for concurrent use.
+func (c *CachedFilter[T]) Add(item T) {
+	c.filter.Add(item)
+}
+
+// Contains tests whether an item might be in the set, consulting the cache
+// first.
+//
+// This method can be called concurrently with other calls to itself, but not
+// [CachedFilter.Add] or [Filter.Add] on the underlying filter.
+func (c *CachedFilter[T]) Contains(item T) bool {
+	c.mu.Lock()
+	if c.resets != c.filter.resets {
+		clear(c.items)
+		c.lru.Init()
+		c.resets = c.filter.resets
+	}
+	if elem, ok := c.items[item]; ok {
+		c.lru.MoveToFront(elem)
+		c.mu.Unlock()
+		return true
+	}
+	c.mu.Unlock()
+
+	if !c.filter.Contains(item) {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[item]; ok {
+		return true // added by a concurrent call
+	}
+	if c.lru.Len() >= c.size {
+		oldest := c.lru.Back()
+		delete(c.items, oldest.Value.(T))
+		c.lru.Remove(oldest)
+	}
+	c.items[item] = c.lru.PushFront(item)
+	return true
+}
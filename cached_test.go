@@ -0,0 +1,90 @@
+package bloom
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCachedFilter(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	c := NewCachedFilter(bf, 2)
+	for i := range 10 {
+		c.Add(i)
+	}
+
+	for _, i := range []int{1, 2, 1, 3} {
+		if !c.Contains(i) {
+			t.Errorf("%d should be in the filter", i)
+		}
+	}
+
+	// 2 was least recently used, so it should have been evicted.
+	if _, ok := c.items[2]; ok {
+		t.Error("2 should have been evicted from the cache")
+	}
+	if got, want := len(c.items), 2; got != want {
+		t.Errorf("got %d cached items, want %d", got, want)
+	}
+
+	// Cached results should survive further adds.
+	c.Add(100)
+	if !c.Contains(1) || !c.Contains(100) {
+		t.Error("1 and 100 should be in the filter")
+	}
+}
+
+func TestCachedFilter_Reset(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01, WithFNVHash())
+	c := NewCachedFilter(bf, 10)
+	c.Add(1)
+	if !c.Contains(1) {
+		t.Fatal("1 should be in the filter")
+	}
+
+	bf.Reset()
+	if c.Contains(1) {
+		t.Error("1 shouldn't be found after the filter is reset")
+	}
+
+	c.Add(1)
+	c.Contains(1)
+	bf.RegenerateSeeds()
+	if c.Contains(1) {
+		t.Error("1 shouldn't be found after the filter's seeds are regenerated")
+	}
+
+	c.Add(1)
+	c.Contains(1)
+	data, err := NewBloomFilter[int](1000, 0.01, WithFNVHash()).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bf.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if c.Contains(1) {
+		t.Error("1 shouldn't be found after decoding an empty filter")
+	}
+}
+
+func TestCachedFilter_Concurrent(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	c := NewCachedFilter(bf, 10)
+	for i := range 100 {
+		c.Add(i)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				if !c.Contains((i + j) % 100) {
+					t.Errorf("%d should be in the filter", (i+j)%100)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
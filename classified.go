@@ -0,0 +1,108 @@
+package bloom
+
+import "fmt"
+
+// ClassSpec describes the sizing of one class of items in a
+// [ClassifiedFilter].
+type ClassSpec struct {
+	ExpectedItems     uint
+	FalsePositiveRate float64
+}
+
+// ClassifiedFilter routes items to one of several sub-filters, according to
+// a caller-supplied classifier, so that each class of items can have its own
+// false positive rate. For example, high-value keys can be given a very low
+// false positive rate, while spending less memory on the rest.
+type ClassifiedFilter[T comparable] struct {
+	classify func(T) int
+	filters  []*Filter[T]
+}
+
+// NewClassifiedFilter creates a new, empty ClassifiedFilter with one
+// sub-filter for each of classes. The classify function must return the index
+// into classes of the class that an item belongs to, and must always return
+// the same class for equal items.
+//
+// It panics if classes is empty, and otherwise under the same conditions as
+// [NewBloomFilter].
+func NewClassifiedFilter[T comparable](classify func(T) int, classes ...ClassSpec) *ClassifiedFilter[T] {
+	if len(classes) == 0 {
+		panic("bloom: at least one class is required")
+	}
+
+	filters := make([]*Filter[T], len(classes))
+	for i, class := range classes {
+		filters[i] = NewBloomFilter[T](class.ExpectedItems, class.FalsePositiveRate)
+	}
+	return &ClassifiedFilter[T]{
+		classify: classify,
+		filters:  filters,
+	}
+}
+
+// Add inserts an item into the sub-filter for its class. It panics if the
+// classifier returns an invalid class.
+//
+// This method is not safe for concurrent use.
+func (cf *ClassifiedFilter[T]) Add(item T) {
+	cf.filterFor(item).Add(item)
+}
+
+// Contains tests whether an item might be in the sub-filter for its class. It
+// panics if the classifier returns an invalid class.
+//
+// This method can be called concurrently with other calls to itself, but not
+// [ClassifiedFilter.Add].
+func (cf *ClassifiedFilter[T]) Contains(item T) bool {
+	return cf.filterFor(item).Contains(item)
+}
+
+func (cf *ClassifiedFilter[T]) filterFor(item T) *Filter[T] {
+	class := cf.classify(item)
+	if class < 0 || class >= len(cf.filters) {
+		panic(fmt.Sprintf("bloom: classifier returned invalid class %d", class))
+	}
+	return cf.filters[class]
+}
+
+// Class returns the sub-filter for the given class, for inspecting per-class
+// statistics. The returned filter must not be modified.
+func (cf *ClassifiedFilter[T]) Class(class int) *Filter[T] {
+	return cf.filters[class]
+}
+
+// Entries returns the total number of items added across all classes.
+func (cf *ClassifiedFilter[T]) Entries() uint {
+	var n uint
+	for _, f := range cf.filters {
+		n += f.entries
+	}
+	return n
+}
+
+// SizeBits returns the total size, in bits, of all of the sub-filters' bit
+// arrays.
+func (cf *ClassifiedFilter[T]) SizeBits() uint {
+	var m uint
+	for _, f := range cf.filters {
+		m += f.m
+	}
+	return m
+}
+
+// EstimatedFalsePositiveRate returns the average of the sub-filters'
+// estimated false positive rates, weighted by the number of items in each.
+// This is the overall rate for queries that are distributed across classes in
+// the same proportions as the items that were added.
+func (cf *ClassifiedFilter[T]) EstimatedFalsePositiveRate() float64 {
+	total := cf.Entries()
+	if total == 0 {
+		return 0
+	}
+
+	var rate float64
+	for _, f := range cf.filters {
+		rate += f.EstimatedFalsePositiveRate() * float64(f.entries) / float64(total)
+	}
+	return rate
+}
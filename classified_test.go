@@ -0,0 +1,51 @@
+package bloom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifiedFilter(t *testing.T) {
+	classify := func(s string) int {
+		if strings.HasPrefix(s, "vip:") {
+			return 0
+		}
+		return 1
+	}
+	cf := NewClassifiedFilter(classify,
+		ClassSpec{ExpectedItems: 100, FalsePositiveRate: 0.0001},
+		ClassSpec{ExpectedItems: 1000, FalsePositiveRate: 0.05},
+	)
+
+	for _, key := range []string{"vip:alice", "bob", "carol"} {
+		cf.Add(key)
+	}
+	for _, key := range []string{"vip:alice", "bob", "carol"} {
+		if !cf.Contains(key) {
+			t.Errorf("'%s' should be in the filter", key)
+		}
+	}
+
+	if got, want := cf.Class(0).entries, uint(1); got != want {
+		t.Errorf("got %d entries in class 0, want %d", got, want)
+	}
+	if got, want := cf.Entries(), uint(3); got != want {
+		t.Errorf("got %d entries, want %d", got, want)
+	}
+	if got, want := cf.SizeBits(), cf.Class(0).m+cf.Class(1).m; got != want {
+		t.Errorf("got %d bits, want %d", got, want)
+	}
+	if cf.Class(0).m/100 <= cf.Class(1).m/1000 {
+		t.Error("the class with a lower FPR should use more bits per item")
+	}
+}
+
+func TestClassifiedFilter_InvalidClass(t *testing.T) {
+	cf := NewClassifiedFilter(func(int) int { return 1 }, ClassSpec{100, 0.01})
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for invalid class")
+		}
+	}()
+	cf.Add(1)
+}
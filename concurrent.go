@@ -0,0 +1,50 @@
+package bloom
+
+import "sync"
+
+// ConcurrentFilter wraps a [Filter] with a lock, so that items can be added
+// and queried from multiple goroutines at once. Queries share the lock, so
+// they run in parallel with each other, but not with additions.
+type ConcurrentFilter[T comparable] struct {
+	mu     sync.RWMutex
+	filter *Filter[T]
+}
+
+// NewConcurrentFilter returns a ConcurrentFilter that guards bf. After this
+// call, bf must only be used through the ConcurrentFilter.
+func NewConcurrentFilter[T comparable](bf *Filter[T]) *ConcurrentFilter[T] {
+	return &ConcurrentFilter[T]{filter: bf}
+}
+
+// Add inserts an item into the filter.
+func (c *ConcurrentFilter[T]) Add(item T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.filter.Add(item)
+}
+
+// AddN inserts each of the provided items into the filter, taking the lock
+// once for the whole batch, which reduces contention for bursty inserts
+// compared with calling [ConcurrentFilter.Add] for each item. It returns the
+// number of items that weren't already present, as reported by
+// [Filter.TestAndAdd], such as for updating metrics; items that are false
+// positives aren't counted.
+func (c *ConcurrentFilter[T]) AddN(items ...T) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var added int
+	for _, item := range items {
+		if !c.filter.TestAndAdd(item) {
+			added++
+		}
+	}
+	return added
+}
+
+// Contains tests whether an item might be in the set.
+// False positives are possible, but false negatives are not.
+func (c *ConcurrentFilter[T]) Contains(item T) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter.Contains(item)
+}
@@ -0,0 +1,79 @@
+package bloom
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConcurrentFilter(t *testing.T) {
+	c := NewConcurrentFilter(NewBloomFilter[int](10000, 0.01, WithFNVHash()))
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				c.Add(g*1000 + i)
+				c.Contains(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range 8000 {
+		if !c.Contains(i) {
+			t.Fatalf("%d should be in the filter", i)
+		}
+	}
+}
+
+func TestConcurrentFilter_AddN(t *testing.T) {
+	c := NewConcurrentFilter(NewBloomFilter[int](1000, 0.01, WithFNVHash()))
+	if got := c.AddN(1, 2, 3); got != 3 {
+		t.Errorf("got %d new items, want 3", got)
+	}
+	if got := c.AddN(2, 3, 4, 4); got != 1 {
+		t.Errorf("got %d new items, want 1", got)
+	}
+	if got := c.AddN(); got != 0 {
+		t.Errorf("got %d new items for no items, want 0", got)
+	}
+	for i := 1; i <= 4; i++ {
+		if !c.Contains(i) {
+			t.Errorf("%d should be in the filter", i)
+		}
+	}
+}
+
+func BenchmarkConcurrentFilterAdd(b *testing.B) {
+	const batch = 64
+	c := NewConcurrentFilter(NewBloomFilter[int](1<<20, 0.01))
+
+	b.Run("Add", func(b *testing.B) {
+		b.SetParallelism(8)
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				for range batch {
+					c.Add(i)
+					i++
+				}
+			}
+		})
+	})
+	b.Run("AddN", func(b *testing.B) {
+		b.SetParallelism(8)
+		b.RunParallel(func(pb *testing.PB) {
+			items := make([]int, batch)
+			i := 0
+			for pb.Next() {
+				for j := range items {
+					items[j] = i
+					i++
+				}
+				c.AddN(items...)
+			}
+		})
+	})
+}
@@ -0,0 +1,279 @@
+package bloom
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// CountingFilter is a Bloom filter that keeps a small counter, rather than a
+// single bit, at each position. This allows items to be removed, at the cost
+// of using more memory than a [Filter] with the same parameters: eight times
+// as much with the default 8-bit counters, or less with [WithCounterBits].
+//
+// Counters saturate at their maximum value rather than wrapping around; a
+// saturated counter is never decremented, since the number of items that
+// incremented it is no longer known. With [WithAutoSeal], a filter whose
+// counters are mostly saturated is converted to a plain [Filter].
+type CountingFilter[T comparable] struct {
+	counters []uint64 // packed counters, width bits each; nil once sealed
+	m        uint     // number of counters
+	width    uint     // bits per counter: 2, 4 or 8
+	seeds    []uint64 // k different seeds for k hash functions
+	entries  uint
+
+	saturated uint       // number of counters at their maximum value
+	sealAt    uint       // number of saturated counters that seals the filter; zero for never
+	sealed    *Filter[T] // the filter's bits once sealed, or nil
+}
+
+// ErrSealed is returned by [CountingFilter.Merge] for a filter that has been
+// sealed by [WithAutoSeal].
+var ErrSealed = errors.New("bloom: counting filter is sealed")
+
+// A CountingOption configures a [CountingFilter] at construction time.
+type CountingOption func(*countingOptions)
+
+// countingOptions holds the configuration set by a list of [CountingOption]
+// values.
+type countingOptions struct {
+	counterBits  uint
+	sealFraction float64
+}
+
+// WithCounterBits sets the number of bits in each counter of a
+// [CountingFilter], which must be 2, 4 or 8. The default is 8.
+//
+// Smaller counters use proportionally less memory, but saturate sooner: a
+// 2-bit counter saturates once three items have incremented it, and a 4-bit
+// counter at fifteen. Saturated counters are never decremented, so removing
+// items leaves them set, and the filter's false positive rate no longer falls
+// as items are removed. Small counters suit sets with few duplicate items.
+func WithCounterBits(bits uint) CountingOption {
+	return func(o *countingOptions) {
+		o.counterBits = bits
+	}
+}
+
+// WithAutoSeal makes a [CountingFilter] convert itself to a plain Bloom
+// filter, reclaiming the memory used by its counters, once more than the
+// given fraction of its counters have saturated. This suits filters that see
+// churn, needing removals, while they are filled, and stabilize afterwards.
+// The fraction must be greater than zero and at most one.
+//
+// Sealing is one-way and loses the ability to remove items: afterwards
+// [CountingFilter.Remove] removes nothing and returns false, and
+// [CountingFilter.Merge] returns [ErrSealed]. Add and Contains are unaffected,
+// and the filter uses between an eighth and a half of its previous memory,
+// depending on [WithCounterBits].
+func WithAutoSeal(fraction float64) CountingOption {
+	return func(o *countingOptions) {
+		o.sealFraction = fraction
+	}
+}
+
+// NewCountingFilter creates a new, empty CountingFilter optimized for the
+// expected number of items and desired false positive rate.
+//
+// It panics if the number of counter bits or the seal fraction is invalid,
+// and otherwise under the same conditions as [NewBloomFilter].
+func NewCountingFilter[T comparable](expectedItems uint, falsePositiveRate float64, opts ...CountingOption) *CountingFilter[T] {
+	o := countingOptions{counterBits: 8}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	switch o.counterBits {
+	case 2, 4, 8:
+	default:
+		panic(fmt.Sprintf("bloom: invalid counter bits %d, must be 2, 4 or 8", o.counterBits))
+	}
+
+	if o.sealFraction != 0 && !(o.sealFraction > 0 && o.sealFraction <= 1) {
+		panic(fmt.Sprintf("bloom: invalid seal fraction %v, must be in (0, 1]", o.sealFraction))
+	}
+
+	m, k := bloomParams(expectedItems, falsePositiveRate)
+	cf := newCountingFilter[T](m, o.counterBits, randomSeeds(k))
+	if o.sealFraction != 0 {
+		cf.sealAt = max(uint(math.Ceil(o.sealFraction*float64(m))), 1)
+	}
+	return cf
+}
+
+// newCountingFilter creates a new, empty CountingFilter with m counters of
+// the given width, and hash functions derived from seeds.
+func newCountingFilter[T comparable](m, width uint, seeds []uint64) *CountingFilter[T] {
+	perWord := 64 / width
+	return &CountingFilter[T]{
+		counters: make([]uint64, (m+perWord-1)/perWord),
+		m:        m,
+		width:    width,
+		seeds:    seeds,
+	}
+}
+
+// maxCount returns the value at which counters saturate.
+func (cf *CountingFilter[T]) maxCount() uint {
+	return 1<<cf.width - 1
+}
+
+// counter returns the value of counter i.
+func (cf *CountingFilter[T]) counter(i uint64) uint {
+	perWord := uint64(64 / cf.width)
+	shift := i % perWord * uint64(cf.width)
+	return uint(cf.counters[i/perWord]>>shift) & cf.maxCount()
+}
+
+// setCounter sets counter i to v, which must be at most cf.maxCount().
+func (cf *CountingFilter[T]) setCounter(i uint64, v uint) {
+	perWord := uint64(64 / cf.width)
+	shift := i % perWord * uint64(cf.width)
+	word := &cf.counters[i/perWord]
+	*word = *word&^(uint64(cf.maxCount())<<shift) | uint64(v)<<shift
+}
+
+// Add inserts an item into the filter.
+//
+// This method is not safe for concurrent use.
+func (cf *CountingFilter[T]) Add(item T) {
+	cf.entries++
+	if cf.sealed != nil {
+		cf.sealed.Add(item)
+		return
+	}
+	for _, seed := range cf.seeds {
+		pos := cf.position(item, seed)
+		if c := cf.counter(pos); c < cf.maxCount() {
+			cf.setCounter(pos, c+1)
+			if c+1 == cf.maxCount() {
+				cf.saturated++
+			}
+		}
+	}
+	cf.maybeSeal()
+}
+
+// maybeSeal seals the filter if enough of its counters have saturated.
+func (cf *CountingFilter[T]) maybeSeal() {
+	if cf.sealAt != 0 && cf.saturated >= cf.sealAt {
+		cf.sealed = cf.ToBloomFilter()
+		cf.counters = nil
+	}
+}
+
+// Sealed reports whether the filter has been converted to a plain Bloom
+// filter by [WithAutoSeal].
+func (cf *CountingFilter[T]) Sealed() bool {
+	return cf.sealed != nil
+}
+
+// Remove deletes an item that was previously added to the filter, returning
+// false if the item is definitely not present. Removing an item that was never
+// added, but which is reported as present due to a false positive, introduces
+// false negatives for other items. A sealed filter removes nothing, and
+// returns false.
+//
+// This method is not safe for concurrent use.
+func (cf *CountingFilter[T]) Remove(item T) bool {
+	if cf.sealed != nil || !cf.Contains(item) {
+		return false
+	}
+
+	cf.entries--
+	for _, seed := range cf.seeds {
+		pos := cf.position(item, seed)
+		if c := cf.counter(pos); c < cf.maxCount() {
+			cf.setCounter(pos, c-1)
+		}
+	}
+	return true
+}
+
+// Contains tests whether an item might be in the set.
+// False positives are possible, but false negatives are not.
+//
+// This method can be called concurrently with other calls to itself, but not
+// [CountingFilter.Add] or [CountingFilter.Remove].
+func (cf *CountingFilter[T]) Contains(item T) bool {
+	if cf.sealed != nil {
+		return cf.sealed.contains(item)
+	}
+	for _, seed := range cf.seeds {
+		if cf.counter(cf.position(item, seed)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge adds every item in other to cf, by adding each of other's counters to
+// the corresponding counter in cf. Sums that exceed the counters' maximum
+// value saturate at that maximum.
+//
+// The filters must have the same configuration; otherwise Merge returns an
+// [*IncompatibleError] and leaves cf unchanged. If either filter is sealed,
+// Merge returns [ErrSealed].
+//
+// This method is not safe for concurrent use with other methods on cf.
+func (cf *CountingFilter[T]) Merge(other *CountingFilter[T]) error {
+	switch {
+	case cf.sealed != nil || other.sealed != nil:
+		return ErrSealed
+	case cf.m != other.m:
+		return &IncompatibleError{Param: "m"}
+	case cf.width != other.width:
+		return &IncompatibleError{Param: "counter bits"}
+	case len(cf.seeds) != len(other.seeds):
+		return &IncompatibleError{Param: "k"}
+	case !slices.Equal(cf.seeds, other.seeds):
+		return &IncompatibleError{Param: "seeds"}
+	}
+
+	for i := range uint64(cf.m) {
+		c := cf.counter(i)
+		sum := min(c+other.counter(i), cf.maxCount())
+		if sum == cf.maxCount() && c != sum {
+			cf.saturated++
+		}
+		cf.setCounter(i, sum)
+	}
+	cf.entries += other.entries
+	cf.maybeSeal()
+	return nil
+}
+
+// SaturatedCells returns the number of counters that have reached their
+// maximum value. Saturated counters are never decremented, so once any are
+// saturated, [CountingFilter.Remove] no longer fully removes the items that
+// map to them, and the filter's false positive rate stops falling as items
+// are removed. Comparing the result with the number of counters shows how
+// far the filter has degraded towards an ordinary Bloom filter.
+func (cf *CountingFilter[T]) SaturatedCells() uint {
+	return cf.saturated
+}
+
+// ToBloomFilter returns a new [Filter] with a bit set at each position where
+// cf has a nonzero counter, which reports the same results as cf's Contains
+// in a fraction of the memory. The result uses the same seeds as cf, and is
+// independent of it: later changes to cf don't affect it.
+func (cf *CountingFilter[T]) ToBloomFilter() *Filter[T] {
+	if cf.sealed != nil {
+		return cf.sealed.Clone()
+	}
+	bf := newFilter[T](cf.m, append([]uint64(nil), cf.seeds...), options{})
+	for i := range uint64(cf.m) {
+		if cf.counter(i) != 0 {
+			wordIndex, mask := i>>6, uint64(1)<<(i&63)
+			bf.bits[wordIndex] |= mask
+		}
+	}
+	bf.entries = cf.entries
+	return bf
+}
+
+// position returns the index of the counter that item maps to for the hash
+// function identified by seed.
+func (cf *CountingFilter[T]) position(item T, seed uint64) uint64 {
+	return reduce(maphashItem(item, seed), cf.m)
+}
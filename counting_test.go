@@ -0,0 +1,228 @@
+package bloom
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCountingFilter(t *testing.T) {
+	cf := NewCountingFilter[string](1000, 0.01)
+	for _, fruit := range []string{"apple", "banana", "orange"} {
+		cf.Add(fruit)
+	}
+
+	if !cf.Contains("apple") {
+		t.Error("'apple' should be in the filter")
+	}
+	if !cf.Remove("apple") {
+		t.Error("removing 'apple' should succeed")
+	}
+	if cf.Contains("apple") {
+		t.Error("'apple' should not be in the filter after removal")
+	}
+	if !cf.Contains("banana") || !cf.Contains("orange") {
+		t.Error("'banana' and 'orange' should still be in the filter")
+	}
+	if cf.Remove("apple") {
+		t.Error("removing 'apple' twice should fail")
+	}
+	if got, want := cf.entries, uint(2); got != want {
+		t.Errorf("got entries=%d, want %d", got, want)
+	}
+}
+
+func TestCountingFilter_Merge(t *testing.T) {
+	a := NewCountingFilter[string](1000, 0.01)
+	b := newCountingFilter[string](a.m, a.width, a.seeds)
+
+	for range 200 {
+		a.Add("apple")
+		b.Add("apple")
+	}
+	b.Add("banana")
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Contains("apple") || !a.Contains("banana") {
+		t.Error("merged filter should contain 'apple' and 'banana'")
+	}
+
+	// 200+200 must saturate rather than wrapping around to 144.
+	for _, seed := range a.seeds {
+		if c := a.counter(a.position("apple", seed)); c != math.MaxUint8 {
+			t.Errorf("got counter %d, want %d", c, math.MaxUint8)
+		}
+	}
+
+	other := NewCountingFilter[string](1000, 0.01)
+	if err := a.Merge(other); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("got error %v, want ErrIncompatible", err)
+	}
+}
+
+func TestCountingFilter_WithCounterBits(t *testing.T) {
+	for _, bits := range []uint{2, 4, 8} {
+		cf := NewCountingFilter[string](1000, 0.01, WithCounterBits(bits))
+		if got, want := len(cf.counters), int((cf.m*bits+63)/64); got != want {
+			t.Errorf("%d bits: got %d words, want %d", bits, got, want)
+		}
+
+		fruits := []string{"apple", "banana", "orange"}
+		for _, fruit := range fruits {
+			cf.Add(fruit)
+		}
+		for _, fruit := range fruits {
+			if !cf.Contains(fruit) {
+				t.Errorf("%d bits: '%s' should be in the filter", bits, fruit)
+			}
+		}
+		if !cf.Remove("apple") || cf.Contains("apple") {
+			t.Errorf("%d bits: 'apple' should be removed", bits)
+		}
+		if !cf.Contains("banana") || !cf.Contains("orange") {
+			t.Errorf("%d bits: 'banana' and 'orange' should still be in the filter", bits)
+		}
+
+		// Counters saturate at their maximum, and then stay there.
+		for range 300 {
+			cf.Add("grape")
+		}
+		for range 300 {
+			cf.Remove("grape")
+		}
+		for _, seed := range cf.seeds {
+			if c := cf.counter(cf.position("grape", seed)); c != 1<<bits-1 {
+				t.Errorf("%d bits: got counter %d, want %d", bits, c, 1<<bits-1)
+			}
+		}
+	}
+
+	a := NewCountingFilter[string](1000, 0.01, WithCounterBits(2))
+	b := newCountingFilter[string](a.m, 4, a.seeds)
+	var ie *IncompatibleError
+	if err := a.Merge(b); !errors.As(err, &ie) || ie.Param != "counter bits" {
+		t.Errorf("got error %v, want IncompatibleError for counter bits", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for invalid counter bits")
+		}
+	}()
+	NewCountingFilter[string](1000, 0.01, WithCounterBits(3))
+}
+
+func TestCountingFilter_SaturatedCells(t *testing.T) {
+	for _, bits := range []uint{2, 4, 8} {
+		cf := NewCountingFilter[string](1000, 0.01, WithCounterBits(bits))
+		if got := cf.SaturatedCells(); got != 0 {
+			t.Errorf("%d bits: got %d saturated cells for empty filter, want 0", bits, got)
+		}
+
+		// Adding an item more times than the counters can count must
+		// not wrap them around to zero.
+		for range 1<<bits + 10 {
+			cf.Add("apple")
+		}
+		if !cf.Contains("apple") {
+			t.Errorf("%d bits: 'apple' should be in the filter", bits)
+		}
+		k := uint(len(cf.seeds))
+		if got := cf.SaturatedCells(); got != k {
+			t.Errorf("%d bits: got %d saturated cells, want %d", bits, got, k)
+		}
+
+		// Merging in the same item saturates no new counters, but a
+		// different one does.
+		other := newCountingFilter[string](cf.m, cf.width, cf.seeds)
+		for range 1 << bits {
+			other.Add("apple")
+			other.Add("banana")
+		}
+		if err := cf.Merge(other); err != nil {
+			t.Fatalf("%d bits: Merge: %v", bits, err)
+		}
+		var want uint
+		for i := range uint64(cf.m) {
+			if cf.counter(i) == cf.maxCount() {
+				want++
+			}
+		}
+		if got := cf.SaturatedCells(); got != want || got <= k {
+			t.Errorf("%d bits: got %d saturated cells after Merge, want %d", bits, got, want)
+		}
+	}
+}
+
+func TestCountingFilter_WithAutoSeal(t *testing.T) {
+	cf := NewCountingFilter[int](100, 0.01, WithCounterBits(2), WithAutoSeal(0.5))
+	cf.Add(-1)
+	if !cf.Remove(-1) || cf.Sealed() {
+		t.Error("an unsaturated filter should remove items")
+	}
+
+	// Adding each item several times saturates its counters.
+	for i := 0; !cf.Sealed(); i++ {
+		if i == 1000 {
+			t.Fatal("filter should have sealed")
+		}
+		for range 3 {
+			cf.Add(i)
+		}
+	}
+	if cf.counters != nil {
+		t.Error("sealing should release the counters")
+	}
+	if got, want := cf.SaturatedCells(), cf.sealAt; got < want {
+		t.Errorf("sealed with %d saturated cells, want at least %d", got, want)
+	}
+
+	cf.Add(-2)
+	for _, item := range []int{0, -2} {
+		if !cf.Contains(item) {
+			t.Errorf("%d should be in the sealed filter", item)
+		}
+	}
+	if cf.Remove(0) || !cf.Contains(0) {
+		t.Error("a sealed filter shouldn't remove items")
+	}
+	if bf := cf.ToBloomFilter(); !bf.Contains(-2) {
+		t.Error("ToBloomFilter should contain items added after sealing")
+	}
+	other := newCountingFilter[int](cf.m, cf.width, cf.seeds)
+	if err := other.Merge(cf); !errors.Is(err, ErrSealed) {
+		t.Errorf("got error %v, want ErrSealed", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for invalid seal fraction")
+		}
+	}()
+	NewCountingFilter[int](100, 0.01, WithAutoSeal(1.5))
+}
+
+func TestCountingFilter_ToBloomFilter(t *testing.T) {
+	cf := NewCountingFilter[int](1000, 0.01)
+	for i := range 1000 {
+		cf.Add(i)
+	}
+	for i := range 500 {
+		cf.Remove(i)
+	}
+
+	bf := cf.ToBloomFilter()
+	for i := range 10000 {
+		if bf.Contains(i) != cf.Contains(i) {
+			t.Fatalf("Contains(%d): got %v, want %v", i, bf.Contains(i), cf.Contains(i))
+		}
+	}
+	if got, want := bf.entries, uint(500); got != want {
+		t.Errorf("got %d entries, want %d", got, want)
+	}
+	if bf.MemoryUsage()*4 > uint(len(cf.counters))*8 {
+		t.Error("the Bloom filter should be much smaller than the counting filter")
+	}
+}
@@ -0,0 +1,79 @@
+package bloom
+
+import "errors"
+
+// A Delta holds the bits set in a filter since an earlier snapshot of its bit
+// array, as returned by [Filter.DeltaSince]. Replicas of the filter can apply
+// deltas with [Filter.ApplyDelta] rather than receiving the whole bit array.
+//
+// Applying a delta only sets bits, so deltas can be applied in any order, and
+// applying one more than once has no further effect: once a replica has
+// applied every delta, its bits match the primary's regardless of reordering
+// or replays by the transport.
+type Delta struct {
+	// ConfigHash is the [Filter.ConfigHash] of the filter the delta was
+	// taken from.
+	ConfigHash uint64
+
+	// Indexes holds the index of each word with newly set bits, in
+	// increasing order, and Words the newly set bits of each.
+	Indexes []int
+	Words   []uint64
+
+	// Entries is the filter's entry count when the delta was taken.
+	Entries uint
+}
+
+// DeltaSince returns the bits set in bf that aren't set in snapshot, a
+// previous result of [Filter.Bits]. It returns an [*IncompatibleError] if
+// snapshot has a different number of words than bf.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) DeltaSince(snapshot []uint64) (Delta, error) {
+	if len(snapshot) != len(bf.bits) {
+		return Delta{}, &IncompatibleError{Param: "m"}
+	}
+	d := Delta{ConfigHash: bf.ConfigHash(), Entries: bf.entries}
+	for i, word := range bf.bits {
+		if i == len(bf.bits)-1 {
+			word &= bf.tailMask()
+		}
+		if added := word &^ snapshot[i]; added != 0 {
+			d.Indexes = append(d.Indexes, i)
+			d.Words = append(d.Words, added)
+		}
+	}
+	return d, nil
+}
+
+// ApplyDelta sets the bits recorded in d, which must have been taken from a
+// filter with the same configuration as bf, such as one created by
+// [NewLike]; otherwise ApplyDelta returns an [*IncompatibleError] and leaves
+// bf unchanged. The entry count of bf becomes the larger of its own and the
+// one recorded in d, so that, like the bits, it doesn't depend on the order
+// in which deltas are applied.
+//
+// This method is not safe for concurrent use with other methods on bf.
+func (bf *Filter[T]) ApplyDelta(d Delta) error {
+	if d.ConfigHash != bf.ConfigHash() {
+		return &IncompatibleError{Param: "config"}
+	}
+	if len(d.Indexes) != len(d.Words) {
+		return errors.New("bloom: delta has mismatched indexes and words")
+	}
+	for _, i := range d.Indexes {
+		if i < 0 || i >= len(bf.bits) {
+			return errors.New("bloom: delta word index out of range")
+		}
+	}
+
+	for j, i := range d.Indexes {
+		bf.bits[i] |= d.Words[j]
+	}
+	bf.bits[len(bf.bits)-1] &= bf.tailMask()
+	if !bf.noEntries {
+		bf.entries = max(bf.entries, d.Entries)
+	}
+	return nil
+}
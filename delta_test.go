@@ -0,0 +1,54 @@
+package bloom
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestBloomFilter_ApplyDelta(t *testing.T) {
+	primary := NewBloomFilter[int](10000, 0.01)
+	replica := NewLike(primary)
+
+	// Take a delta after each batch of items.
+	var deltas []Delta
+	snapshot := primary.Bits()
+	for batch := range 10 {
+		for i := range 1000 {
+			primary.Add(batch*1000 + i)
+		}
+		d, err := primary.DeltaSince(snapshot)
+		if err != nil {
+			t.Fatalf("DeltaSince: %v", err)
+		}
+		deltas = append(deltas, d)
+		snapshot = primary.Bits()
+	}
+
+	// Replay some deltas, and deliver them all out of order.
+	deltas = append(deltas, deltas[3], deltas[7], deltas[3])
+	rand.Shuffle(len(deltas), func(i, j int) {
+		deltas[i], deltas[j] = deltas[j], deltas[i]
+	})
+	for _, d := range deltas {
+		if err := replica.ApplyDelta(d); err != nil {
+			t.Fatalf("ApplyDelta: %v", err)
+		}
+	}
+
+	if !slices.Equal(replica.Bits(), primary.Bits()) {
+		t.Error("replica bits don't match the primary")
+	}
+	if replica.entries != primary.entries {
+		t.Errorf("got %d entries, want %d", replica.entries, primary.entries)
+	}
+
+	other := NewBloomFilter[int](10000, 0.01)
+	if err := other.ApplyDelta(deltas[0]); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("got error %v, want ErrIncompatible", err)
+	}
+	if _, err := primary.DeltaSince(snapshot[1:]); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("got error %v, want ErrIncompatible", err)
+	}
+}
@@ -0,0 +1,98 @@
+package bloom
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Directory holds a set of named filters, such as one per shard of a data
+// store, and finds which of them might contain an item.
+//
+// A Directory is safe for concurrent use, but its filters must not be
+// modified while the Directory is being queried.
+type Directory[T comparable] struct {
+	mu      sync.RWMutex
+	names   []string // sorted
+	filters map[string]*Filter[T]
+}
+
+// NewDirectory creates a new, empty Directory.
+func NewDirectory[T comparable]() *Directory[T] {
+	return &Directory[T]{
+		filters: make(map[string]*Filter[T]),
+	}
+}
+
+// Set adds a filter to the directory under name, replacing any filter that
+// already has that name.
+func (d *Directory[T]) Set(name string, bf *Filter[T]) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.filters[name]; !ok {
+		i, _ := slices.BinarySearch(d.names, name)
+		d.names = slices.Insert(d.names, i, name)
+	}
+	d.filters[name] = bf
+}
+
+// Remove removes the filter with the given name from the directory, if there
+// is one.
+func (d *Directory[T]) Remove(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.filters[name]; !ok {
+		return
+	}
+	delete(d.filters, name)
+	i, _ := slices.BinarySearch(d.names, name)
+	d.names = slices.Delete(d.names, i, i+1)
+}
+
+// Get returns the filter with the given name, or nil if there is none.
+func (d *Directory[T]) Get(name string) *Filter[T] {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.filters[name]
+}
+
+// Len returns the number of filters in the directory.
+func (d *Directory[T]) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.filters)
+}
+
+// ShardsFor returns the names of the filters that might contain item, in
+// sorted order. False positives are possible, so an item may not be present
+// in every shard returned, but every shard that it was added to is returned.
+func (d *Directory[T]) ShardsFor(item T) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var names []string
+	for _, name := range d.names {
+		if d.filters[name].Contains(item) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ContainsContext reports whether any filter in the directory might contain
+// item, checking ctx for cancellation before probing each filter and
+// returning its error if it is done. Cancellation is checked between
+// filters, not during a probe, so a single slow filter still delays the
+// result.
+func (d *Directory[T]) ContainsContext(ctx context.Context, item T) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, name := range d.names {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		if d.filters[name].Contains(item) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,72 @@
+package bloom
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDirectory(t *testing.T) {
+	d := NewDirectory[string]()
+	shards := map[string][]string{
+		"shard-b": {"apple", "banana"},
+		"shard-a": {"apple", "orange"},
+		"shard-c": {"grape"},
+	}
+	for name, items := range shards {
+		bf := NewBloomFilter[string](100, 0.0001)
+		for _, item := range items {
+			bf.Add(item)
+		}
+		d.Set(name, bf)
+	}
+
+	if got, want := d.ShardsFor("apple"), []string{"shard-a", "shard-b"}; !slices.Equal(got, want) {
+		t.Errorf("got shards %v, want %v", got, want)
+	}
+	if got, want := d.ShardsFor("grape"), []string{"shard-c"}; !slices.Equal(got, want) {
+		t.Errorf("got shards %v, want %v", got, want)
+	}
+
+	d.Remove("shard-a")
+	d.Remove("shard-a")
+	if got, want := d.ShardsFor("apple"), []string{"shard-b"}; !slices.Equal(got, want) {
+		t.Errorf("got shards %v after remove, want %v", got, want)
+	}
+	if got, want := d.Len(), 2; got != want {
+		t.Errorf("got %d filters, want %d", got, want)
+	}
+
+	// Replacing a filter doesn't duplicate its name.
+	d.Set("shard-b", NewBloomFilter[string](100, 0.0001))
+	if got := d.ShardsFor("apple"); len(got) != 0 {
+		t.Errorf("got shards %v after replace, want none", got)
+	}
+	if got, want := d.Len(), 2; got != want {
+		t.Errorf("got %d filters after replace, want %d", got, want)
+	}
+}
+
+func TestDirectory_ContainsContext(t *testing.T) {
+	d := NewDirectory[string]()
+	for _, name := range []string{"a", "b"} {
+		bf := NewBloomFilter[string](100, 0.0001, WithFNVHash())
+		bf.Add(name)
+		d.Set(name, bf)
+	}
+
+	ctx := context.Background()
+	if ok, err := d.ContainsContext(ctx, "b"); !ok || err != nil {
+		t.Errorf("got %v, %v for 'b', want true, nil", ok, err)
+	}
+	if ok, err := d.ContainsContext(ctx, "c"); ok || err != nil {
+		t.Errorf("got %v, %v for 'c', want false, nil", ok, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := d.ContainsContext(ctx, "b"); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,51 @@
+package bloom
+
+import "unsafe"
+
+// EncodedFilter is a Bloom filter for items of any type, which hashes the
+// bytes of each item's encoding as given by a caller-supplied function,
+// rather than the item itself. This gives full control over which items are
+// considered equal, such as by normalizing case or ignoring some fields of a
+// struct, and doesn't depend on how [hash/maphash.WriteComparable] treats
+// the item's type.
+type EncodedFilter[T any] struct {
+	filter *Filter[string]
+	encode func(T) []byte
+}
+
+// NewBloomFilterWithEncoder creates a new, empty EncodedFilter optimized for
+// the expected number of items and desired false positive rate. Items with
+// equal encodings are treated as the same item.
+//
+// It panics under the same conditions as [NewBloomFilter].
+func NewBloomFilterWithEncoder[T any](expectedItems uint, falsePositiveRate float64, encode func(T) []byte, opts ...Option) *EncodedFilter[T] {
+	return &EncodedFilter[T]{
+		filter: NewBloomFilter[string](expectedItems, falsePositiveRate, opts...),
+		encode: encode,
+	}
+}
+
+// Add inserts an item into the filter.
+//
+// This method is not safe for concurrent use.
+func (ef *EncodedFilter[T]) Add(item T) {
+	b := ef.encode(item)
+	// The filter doesn't retain the string, so it's safe to avoid the copy.
+	ef.filter.Add(unsafe.String(unsafe.SliceData(b), len(b)))
+}
+
+// Contains tests whether an item might be in the set.
+// False positives are possible, but false negatives are not.
+//
+// This method can be called concurrently with other calls to itself, but not
+// [EncodedFilter.Add], provided that the encoding function is safe for
+// concurrent use.
+func (ef *EncodedFilter[T]) Contains(item T) bool {
+	return ContainsBytes(ef.filter, ef.encode(item))
+}
+
+// Filter returns the underlying filter of encoded items, which can be
+// serialized, inspected or combined like any other [Filter].
+func (ef *EncodedFilter[T]) Filter() *Filter[string] {
+	return ef.filter
+}
@@ -0,0 +1,27 @@
+package bloom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncodedFilter(t *testing.T) {
+	type user struct {
+		Email string
+		Tags  []string // not comparable, and ignored by the encoding
+	}
+	ef := NewBloomFilterWithEncoder(1000, 0.01, func(u user) []byte {
+		return []byte(strings.ToLower(u.Email))
+	}, WithFNVHash())
+
+	ef.Add(user{Email: "Alice@Example.com", Tags: []string{"admin"}})
+	if !ef.Contains(user{Email: "alice@example.com"}) {
+		t.Error("users with equal encodings should be treated as the same item")
+	}
+	if ef.Contains(user{Email: "bob@example.com"}) {
+		t.Error("'bob@example.com' should not be in the filter")
+	}
+	if !ef.Filter().Contains("alice@example.com") {
+		t.Error("the underlying filter should contain the encoding")
+	}
+}
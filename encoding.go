@@ -0,0 +1,474 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/maphash"
+	"io"
+	"math"
+	"reflect"
+)
+
+// The binary format produced by [Filter.MarshalBinary] is, with all integers
+// encoded as little-endian:
+//
+//	magic   [4]byte  "BLMF"
+//	version uint8    currently 3
+//	flags   uint8    bit 0: entry counting disabled; bit 1: FNV hashing;
+//	                 bit 2: chunked bits; bit 3: distinct entry counting;
+//	                 bit 4: trimmed bits
+//	_       [2]byte  reserved, must be zero
+//	process uint64   identifies the process that wrote the filter, or zero if
+//	                 hashing is independent of the process
+//	m       uint64   size of bit array
+//	k       uint64   number of hash functions
+//	entries uint64
+//	sizedN  uint64   expected items the filter was sized for, or zero
+//	sizedP  float64  target false positive rate, or zero
+//	seeds   [k]uint64
+//	bits    [(m+63)/64]uint64
+//	crc     uint32   CRC-32C (Castagnoli) of all preceding bytes
+//
+// Version 2 of the format is identical, but without crc, and version 1 is
+// also without sizedN and sizedP.
+//
+// The format written by [Filter.WriteTo] sets the chunked bits flag, and
+// replaces bits with a sequence of chunks, each consisting of a uint32 count
+// of words followed by that many uint64 words, and terminated by a chunk with
+// a count of zero. The chunked format was introduced in version 2.
+//
+// The format written by [Filter.MarshalBinaryTrimmed] sets the trimmed bits
+// flag, and omits up to 2^20 trailing zero words of bits, so that bits may
+// have fewer than (m+63)/64 words; the omitted words are zero.
+const (
+	encodingMagic   = "BLMF"
+	encodingVersion = 3
+
+	encodingHeaderSize   = 4 + 1 + 1 + 2 + 8 + 8 + 8 + 8 + 8 + 8
+	encodingHeaderSizeV1 = encodingHeaderSize - 16
+	checksumSize         = 4
+
+	flagNoEntries = 1 << 0
+	flagFNVHash   = 1 << 1
+	flagChunked   = 1 << 2
+	flagDistinct  = 1 << 3
+	flagTrimmed   = 1 << 4
+
+	// chunkWords is the number of words in each chunk written by WriteTo.
+	chunkWords = 1 << 17 // 1 MiB
+
+	// maxEncodedSeeds bounds the number of hash functions accepted when
+	// decoding, so that corrupt input can't cause a huge allocation.
+	maxEncodedSeeds = 1 << 16
+
+	// maxTrimmedWords bounds the number of words omitted from trimmed
+	// input, for the same reason.
+	maxTrimmedWords = 1 << 20 // 8 MiB
+)
+
+// processTag identifies the current process's hashing; see [processSeed].
+// Since [hash/maphash] hashes differ between processes, a filter serialized in
+// one process cannot be queried in another.
+var processTag = maphash.String(processSeed, "github.com/andrew-d/bloom")
+
+// crcTable is the table for the checksum that ends the binary format.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrChecksum is returned when deserializing a filter whose checksum doesn't
+// match its contents, such as after corruption on disk.
+var ErrChecksum = errors.New("bloom: checksum mismatch")
+
+// ErrDifferentProcess is returned when deserializing a filter that was
+// serialized by a different process, and whose hashes are therefore not
+// reproducible in this one.
+var ErrDifferentProcess = errors.New("bloom: filter was serialized by a different process")
+
+// header is the fixed-size portion of the binary format.
+type header struct {
+	version       byte
+	flags         byte
+	process       uint64
+	m             uint64
+	k             uint64
+	entries       uint64
+	expectedItems uint64
+	targetFPR     float64
+}
+
+// header returns the header describing bf.
+func (bf *Filter[T]) header() header {
+	h := header{
+		version:       encodingVersion,
+		process:       processTag,
+		m:             uint64(bf.m),
+		k:             uint64(len(bf.seeds)),
+		entries:       uint64(bf.entries),
+		expectedItems: uint64(bf.expectedItems),
+		targetFPR:     bf.targetFPR,
+	}
+	if bf.noEntries {
+		h.flags |= flagNoEntries
+	}
+	if bf.fnv {
+		h.flags |= flagFNVHash
+		h.process = 0
+	}
+	if bf.distinct {
+		h.flags |= flagDistinct
+	}
+	return h
+}
+
+func (h header) append(buf []byte) []byte {
+	buf = append(buf, encodingMagic...)
+	buf = append(buf, h.version, h.flags, 0, 0)
+	buf = binary.LittleEndian.AppendUint64(buf, h.process)
+	buf = binary.LittleEndian.AppendUint64(buf, h.m)
+	buf = binary.LittleEndian.AppendUint64(buf, h.k)
+	buf = binary.LittleEndian.AppendUint64(buf, h.entries)
+	buf = binary.LittleEndian.AppendUint64(buf, h.expectedItems)
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(h.targetFPR))
+	return buf
+}
+
+// parseHeader decodes a header from the start of data, returning the number
+// of bytes consumed.
+func parseHeader(data []byte) (h header, n int, err error) {
+	if len(data) < encodingHeaderSizeV1 {
+		return h, 0, errors.New("bloom: data too short")
+	}
+	if string(data[:4]) != encodingMagic {
+		return h, 0, errors.New("bloom: invalid magic")
+	}
+	h.version = data[4]
+	if h.version < 1 || h.version > encodingVersion {
+		return h, 0, fmt.Errorf("bloom: unsupported version %d", h.version)
+	}
+	if h.version >= 2 && len(data) < encodingHeaderSize {
+		return h, 0, errors.New("bloom: data too short")
+	}
+	h.flags = data[5]
+	if h.flags&^(flagNoEntries|flagFNVHash|flagChunked|flagDistinct|flagTrimmed) != 0 || data[6] != 0 || data[7] != 0 {
+		return h, 0, errors.New("bloom: invalid flags")
+	}
+
+	h.process = binary.LittleEndian.Uint64(data[8:])
+	h.m = binary.LittleEndian.Uint64(data[16:])
+	h.k = binary.LittleEndian.Uint64(data[24:])
+	h.entries = binary.LittleEndian.Uint64(data[32:])
+	n = encodingHeaderSizeV1
+	if h.version >= 2 {
+		h.expectedItems = binary.LittleEndian.Uint64(data[40:])
+		h.targetFPR = math.Float64frombits(binary.LittleEndian.Uint64(data[48:]))
+		n = encodingHeaderSize
+	}
+
+	if h.m == 0 || h.m > math.MaxInt-63 || h.k == 0 || h.k > maxEncodedSeeds {
+		return h, 0, errors.New("bloom: invalid parameters")
+	}
+	if !(h.targetFPR >= 0 && h.targetFPR <= 1) {
+		return h, 0, errors.New("bloom: invalid target false positive rate")
+	}
+	return h, n, nil
+}
+
+// hasChecksum reports whether the data described by h ends with a checksum.
+func (h header) hasChecksum() bool {
+	return h.version >= 3
+}
+
+// numWords returns the number of words in the bit array described by h.
+func (h header) numWords() uint64 {
+	return (h.m + 63) / 64
+}
+
+// checkHeader returns an error if a filter described by h can't be used as a
+// Filter[T] in this process.
+func checkHeader[T comparable](h header) error {
+	if h.flags&flagFNVHash != 0 {
+		return checkFNVType(reflect.TypeFor[T]())
+	}
+	if h.process != processTag {
+		return ErrDifferentProcess
+	}
+	return nil
+}
+
+// newDecodedFilter creates the filter described by h.
+func newDecodedFilter[T comparable](h header, seeds, words []uint64) *Filter[T] {
+	return &Filter[T]{
+		bits:      words,
+		m:         uint(h.m),
+		seeds:     seeds,
+		entries:   uint(h.entries),
+		noEntries: h.flags&flagNoEntries != 0,
+		fnv:       h.flags&flagFNVHash != 0,
+		distinct:  h.flags&flagDistinct != 0,
+
+		expectedItems: uint(h.expectedItems),
+		targetFPR:     h.targetFPR,
+	}
+}
+
+// setDecoded replaces bf with decoded, keeping bf's hooks, saturation check
+// and interval stats, which aren't encoded. Since that can clear bits of bf,
+// it counts as a reset.
+func (bf *Filter[T]) setDecoded(decoded *Filter[T]) {
+	decoded.hooks = bf.hooks
+	decoded.saturation = bf.saturation
+	decoded.interval = bf.interval
+	decoded.resets = bf.resets + 1
+	*bf = *decoded
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], encoding the filter's
+// configuration and contents.
+//
+// Since the hash functions used by a Filter are only consistent within a
+// single process, the result can only be decoded by the same process unless
+// the filter was created with [WithFNVHash]; see [Filter.UnmarshalBinary].
+func (bf *Filter[T]) MarshalBinary() ([]byte, error) {
+	return bf.marshal(bf.header(), bf.bits), nil
+}
+
+// MarshalBinaryTrimmed is like [Filter.MarshalBinary], but omits the words at
+// the end of the bit array in which no bits are set, which shrinks the result
+// for lightly loaded filters whose set bits are all near the start of the
+// array. [Filter.UnmarshalBinary] restores the omitted words. At most 8 MiB
+// of words are omitted, so that decoding never allocates much more than the
+// size of its input. Older versions of this package can't decode the result.
+func (bf *Filter[T]) MarshalBinaryTrimmed() ([]byte, error) {
+	words := bf.bits
+	keep := max(len(words)-maxTrimmedWords, 0)
+	for len(words) > keep && words[len(words)-1] == 0 {
+		words = words[:len(words)-1]
+	}
+
+	h := bf.header()
+	h.flags |= flagTrimmed
+	return bf.marshal(h, words), nil
+}
+
+// marshal encodes h, followed by the filter's seeds and words, and a checksum.
+func (bf *Filter[T]) marshal(h header, words []uint64) []byte {
+	buf := make([]byte, 0, encodingHeaderSize+8*(len(bf.seeds)+len(words)))
+	buf = h.append(buf)
+	for _, seed := range bf.seeds {
+		buf = binary.LittleEndian.AppendUint64(buf, seed)
+	}
+	for _, word := range words {
+		buf = binary.LittleEndian.AppendUint64(buf, word)
+	}
+	return binary.LittleEndian.AppendUint32(buf, crc32.Checksum(buf, crcTable))
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], replacing the
+// filter's configuration and contents with those decoded from data, as
+// produced by [Filter.MarshalBinary] or [Filter.MarshalBinaryTrimmed]. The
+// filter keeps any hooks, saturation check and interval stats it was created
+// with, since those aren't encoded.
+//
+// It returns [ErrDifferentProcess] if data was produced by a different
+// process, unless the filter uses [WithFNVHash], and [ErrChecksum] if data has
+// been corrupted.
+func (bf *Filter[T]) UnmarshalBinary(data []byte) error {
+	h, n, err := parseHeader(data)
+	if err != nil {
+		return err
+	}
+	if h.flags&flagChunked != 0 {
+		return errors.New("bloom: chunked data must be read with ReadFrom")
+	}
+	if err := checkHeader[T](h); err != nil {
+		return err
+	}
+	if h.hasChecksum() {
+		if len(data) < n+checksumSize {
+			return errors.New("bloom: invalid data length")
+		}
+		trailer := data[len(data)-checksumSize:]
+		data = data[:len(data)-checksumSize]
+		if crc32.Checksum(data, crcTable) != binary.LittleEndian.Uint32(trailer) {
+			return ErrChecksum
+		}
+	}
+	data = data[n:]
+
+	n = len(data) / 8
+	switch {
+	case len(data)%8 != 0,
+		h.flags&flagTrimmed == 0 && uint64(n) != h.k+h.numWords(),
+		uint64(n) < h.k || uint64(n) > h.k+h.numWords(),
+		h.k+h.numWords()-uint64(n) > maxTrimmedWords:
+		return errors.New("bloom: invalid data length")
+	}
+	// Any words omitted by trimming are left as zero.
+	words := make([]uint64, h.k+h.numWords())
+	for i := range n {
+		words[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+
+	bf.setDecoded(newDecodedFilter[T](h, words[:h.k:h.k], words[h.k:]))
+	return nil
+}
+
+// WriteTo implements [io.WriterTo], writing the filter to w in a streaming
+// format that splits the bit array into fixed-size chunks. Unlike
+// [Filter.MarshalBinary], this never buffers the whole filter in memory,
+// which makes it suitable for very large filters. Use [Filter.ReadFrom] to
+// decode the result.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) WriteTo(w io.Writer) (int64, error) {
+	h := bf.header()
+	h.flags |= flagChunked
+
+	crc := crc32.New(crcTable)
+	buf := h.append(nil)
+	for _, seed := range bf.seeds {
+		buf = binary.LittleEndian.AppendUint64(buf, seed)
+	}
+	crc.Write(buf)
+	n, err := w.Write(buf)
+	total := int64(n)
+	if err != nil {
+		return total, err
+	}
+
+	words := bf.bits
+	buf = make([]byte, 0, 4+8*min(len(words), chunkWords))
+	for {
+		chunk := words[:min(len(words), chunkWords)]
+		words = words[len(chunk):]
+
+		buf = binary.LittleEndian.AppendUint32(buf[:0], uint32(len(chunk)))
+		for _, word := range chunk {
+			buf = binary.LittleEndian.AppendUint64(buf, word)
+		}
+		crc.Write(buf)
+		if len(chunk) == 0 {
+			// Follow the final, empty chunk with the checksum.
+			buf = binary.LittleEndian.AppendUint32(buf, crc.Sum32())
+		}
+		n, err := w.Write(buf)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+		if len(chunk) == 0 {
+			return total, nil
+		}
+	}
+}
+
+// ReadFrom implements [io.ReaderFrom], replacing the filter's configuration
+// and contents with those read from r, which must contain a filter written by
+// [Filter.WriteTo]. The bit array is reconstructed incrementally as each
+// chunk is read, and no data beyond the end of the filter is read from r.
+// As with [Filter.UnmarshalBinary], the filter keeps any hooks, saturation
+// check and interval stats it was created with.
+//
+// It returns [ErrDifferentProcess] if the filter was written by a different
+// process, unless the filter uses [WithFNVHash], and [ErrChecksum] if the data
+// has been corrupted.
+func (bf *Filter[T]) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r, crc: crc32.New(crcTable)}
+
+	buf := make([]byte, encodingHeaderSize)
+	if _, err := io.ReadFull(cr, buf); err != nil {
+		return cr.n, noEOF(err)
+	}
+	h, _, err := parseHeader(buf)
+	if err != nil {
+		return cr.n, err
+	}
+	if h.version < 2 || h.flags&flagChunked == 0 || h.flags&flagTrimmed != 0 {
+		return cr.n, errors.New("bloom: data is not in the chunked format")
+	}
+	if err := checkHeader[T](h); err != nil {
+		return cr.n, err
+	}
+
+	seeds, err := readWords(cr, nil, h.k, buf)
+	if err != nil {
+		return cr.n, err
+	}
+
+	// Grow the bit array chunk by chunk rather than trusting m, so that
+	// corrupt input can't cause a huge allocation.
+	numWords := h.numWords()
+	words := make([]uint64, 0, min(numWords, chunkWords))
+	for {
+		if _, err := io.ReadFull(cr, buf[:4]); err != nil {
+			return cr.n, noEOF(err)
+		}
+		count := uint64(binary.LittleEndian.Uint32(buf))
+		if count == 0 {
+			break
+		}
+		if count > chunkWords || count > numWords-uint64(len(words)) {
+			return cr.n, errors.New("bloom: invalid chunk length")
+		}
+		if words, err = readWords(cr, words, count, buf); err != nil {
+			return cr.n, err
+		}
+	}
+	if uint64(len(words)) != numWords {
+		return cr.n, errors.New("bloom: too few words in data")
+	}
+	if h.hasChecksum() {
+		want := cr.crc.Sum32()
+		if _, err := io.ReadFull(cr, buf[:checksumSize]); err != nil {
+			return cr.n, noEOF(err)
+		}
+		if binary.LittleEndian.Uint32(buf) != want {
+			return cr.n, ErrChecksum
+		}
+	}
+
+	bf.setDecoded(newDecodedFilter[T](h, seeds, words))
+	return cr.n, nil
+}
+
+// readWords reads count little-endian words from r, appending them to words.
+// It uses buf as scratch space, growing it as needed.
+func readWords(r io.Reader, words []uint64, count uint64, buf []byte) ([]uint64, error) {
+	if uint64(cap(buf)) < 8*count {
+		buf = make([]byte, 8*count)
+	}
+	buf = buf[:8*count]
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return words, noEOF(err)
+	}
+	for i := range count {
+		words = append(words, binary.LittleEndian.Uint64(buf[8*i:]))
+	}
+	return words, nil
+}
+
+// noEOF converts io.EOF to io.ErrUnexpectedEOF, since the data is incomplete
+// if we reach the end of it while reading.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// countingReader counts the bytes read from r, and computes their checksum.
+type countingReader struct {
+	r   io.Reader
+	n   int64
+	crc hash.Hash32
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	cr.crc.Write(p[:n])
+	return n, err
+}
@@ -0,0 +1,320 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestBloomFilter_MarshalBinary(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01)
+	for _, fruit := range []string{"apple", "banana", "orange"} {
+		bf.Add(fruit)
+	}
+
+	data, err := bf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got Filter[string]
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if got.m != bf.m || got.entries != bf.entries {
+		t.Errorf("got m=%d entries=%d, want m=%d entries=%d", got.m, got.entries, bf.m, bf.entries)
+	}
+	if got.expectedItems != bf.expectedItems || got.targetFPR != bf.targetFPR {
+		t.Errorf("got sized for (%d, %v), want (%d, %v)", got.expectedItems, got.targetFPR, bf.expectedItems, bf.targetFPR)
+	}
+	if !slices.Equal(got.seeds, bf.seeds) {
+		t.Error("seeds differ after round-trip")
+	}
+	if !slices.Equal(got.bits, bf.bits) {
+		t.Error("bits differ after round-trip")
+	}
+	for _, fruit := range []string{"apple", "banana", "orange"} {
+		if !got.Contains(fruit) {
+			t.Errorf("'%s' should be in the decoded filter", fruit)
+		}
+	}
+}
+
+func TestBloomFilter_MarshalBinaryTrimmed(t *testing.T) {
+	bf := NewBloomFilter[int](100000, 0.01)
+	bf.bits[0] = 0b1011
+	bf.bits[10] = 1 << 63
+	bf.entries = 3
+
+	full, err := bf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := bf.MarshalBinaryTrimmed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(data), len(full)-8*(len(bf.bits)-11); got != want {
+		t.Errorf("got %d bytes, want %d", got, want)
+	}
+
+	var got Filter[int]
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.bits, bf.bits) || !slices.Equal(got.seeds, bf.seeds) || got.entries != bf.entries {
+		t.Error("filter differs after round-trip")
+	}
+
+	// An empty filter trims every word.
+	empty := NewBloomFilter[int](1000, 0.01)
+	if data, err = empty.MarshalBinaryTrimmed(); err != nil {
+		t.Fatal(err)
+	}
+	if err := got.UnmarshalBinary(data); err != nil || len(got.bits) != len(empty.bits) {
+		t.Errorf("got %d words and error %v, want %d words", len(got.bits), err, len(empty.bits))
+	}
+
+	// Without the trimmed flag, the bits must be complete.
+	data[5] &^= flagTrimmed
+	if err := got.UnmarshalBinary(data); err == nil {
+		t.Error("expected error for untrimmed data missing words")
+	}
+
+	// A large empty filter keeps enough words to bound the padding.
+	large := NewBloomFilter[int](2*64*maxTrimmedWords, 0.5)
+	if data, err = large.MarshalBinaryTrimmed(); err != nil {
+		t.Fatal(err)
+	}
+	if err := got.UnmarshalBinary(data); err != nil || len(got.bits) != len(large.bits) {
+		t.Errorf("got %d words and error %v, want %d words", len(got.bits), err, len(large.bits))
+	}
+}
+
+func TestBloomFilter_UnmarshalBinary_TrimmedHugeM(t *testing.T) {
+	data, err := NewBloomFilter[int](10, 0.5).MarshalBinaryTrimmed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Claim a bit array far larger than any trimmed encoding could omit.
+	binary.LittleEndian.PutUint64(data[16:], 1<<40)
+	body := data[:len(data)-checksumSize]
+	binary.LittleEndian.PutUint32(data[len(body):], crc32.Checksum(body, crcTable))
+
+	var bf Filter[int]
+	if err := bf.UnmarshalBinary(data); err == nil {
+		t.Error("expected error for trimmed data with a huge m")
+	}
+}
+
+func TestBloomFilter_MarshalBinary_ByteOrder(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01, WithFNVHash())
+	bf.Add("apple")
+
+	data, err := bf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every integer is little-endian, whatever the host's byte order.
+	var want []byte
+	want = binary.LittleEndian.AppendUint64(want, uint64(bf.m))
+	want = binary.LittleEndian.AppendUint64(want, uint64(len(bf.seeds)))
+	if !bytes.Equal(data[16:32], want) {
+		t.Errorf("got m and k encoded as %x, want %x", data[16:32], want)
+	}
+	want = nil
+	for _, v := range append(bf.Seeds(), bf.bits...) {
+		want = binary.LittleEndian.AppendUint64(want, v)
+	}
+	if !bytes.Equal(data[encodingHeaderSize:len(data)-checksumSize], want) {
+		t.Error("seeds and bits should be encoded as little-endian words")
+	}
+
+	// Decoding the little-endian words as big-endian, as a host that
+	// copied its memory directly would, gives different values; if the
+	// format depended on the host's byte order, a filter written on one
+	// host would be silently corrupted on the other.
+	swapped := slices.Clone(data)
+	for i := 16; i+8 <= len(swapped); i += 8 {
+		binary.BigEndian.PutUint64(swapped[i:], binary.LittleEndian.Uint64(data[i:]))
+	}
+	var got Filter[string]
+	if err := got.UnmarshalBinary(swapped); err == nil {
+		t.Error("expected error decoding byte-swapped data")
+	}
+
+	// Re-encoding the decoded words gives back the original data.
+	restored := slices.Clone(swapped)
+	for i := 16; i+8 <= len(restored); i += 8 {
+		binary.LittleEndian.PutUint64(restored[i:], binary.BigEndian.Uint64(swapped[i:]))
+	}
+	if err := got.UnmarshalBinary(restored); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.bits, bf.bits) || !got.Contains("apple") {
+		t.Error("filter differs after swapping back to little-endian")
+	}
+}
+
+func TestBloomFilter_UnmarshalBinary_Version1(t *testing.T) {
+	bf := NewBloomFilter[string](100, 0.01)
+	bf.Add("apple")
+	data, err := bf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 1 lacks the parameters the filter was sized for, and the
+	// checksum.
+	v1 := slices.Concat(data[:encodingHeaderSizeV1], data[encodingHeaderSize:len(data)-checksumSize])
+	v1[4] = 1
+
+	var got Filter[string]
+	if err := got.UnmarshalBinary(v1); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Contains("apple") {
+		t.Error("'apple' should be in the decoded filter")
+	}
+	if got.expectedItems != 0 || got.targetFPR != 0 {
+		t.Errorf("got sized for (%d, %v), want zero", got.expectedItems, got.targetFPR)
+	}
+}
+
+func TestBloomFilter_UnmarshalBinary_Version2(t *testing.T) {
+	bf := NewBloomFilter[string](100, 0.01)
+	bf.Add("apple")
+	data, err := bf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 2 lacks the checksum.
+	v2 := slices.Clone(data[:len(data)-checksumSize])
+	v2[4] = 2
+
+	var got Filter[string]
+	if err := got.UnmarshalBinary(v2); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Contains("apple") || got.expectedItems != bf.expectedItems {
+		t.Error("filter differs after decoding version 2")
+	}
+}
+
+func TestBloomFilter_UnmarshalBinary_Checksum(t *testing.T) {
+	bf := NewBloomFilter[string](100, 0.01)
+	bf.Add("apple")
+	data, err := bf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flipping any bit of the seeds, bits or checksum is detected.
+	for _, i := range []int{encodingHeaderSize, len(data) - checksumSize - 1, len(data) - 1} {
+		corrupt := slices.Clone(data)
+		corrupt[i] ^= 0x10
+		var got Filter[string]
+		if err := got.UnmarshalBinary(corrupt); !errors.Is(err, ErrChecksum) {
+			t.Errorf("byte %d flipped: got error %v, want ErrChecksum", i, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := bf.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	chunked := buf.Bytes()
+	chunked[len(chunked)-checksumSize-8] ^= 0x10
+	var got Filter[string]
+	if _, err := got.ReadFrom(bytes.NewReader(chunked)); !errors.Is(err, ErrChecksum) {
+		t.Errorf("got error %v from ReadFrom, want ErrChecksum", err)
+	}
+}
+
+func TestBloomFilter_UnmarshalBinary_Invalid(t *testing.T) {
+	bf := NewBloomFilter[string](100, 0.01)
+	data, err := bf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got Filter[string]
+	if err := got.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("expected error for truncated data")
+	}
+	if err := got.UnmarshalBinary(append(slices.Clone(data), 0)); err == nil {
+		t.Error("expected error for trailing data")
+	}
+
+	badMagic := slices.Clone(data)
+	badMagic[0] = 'X'
+	if err := got.UnmarshalBinary(badMagic); err == nil {
+		t.Error("expected error for invalid magic")
+	}
+
+	otherProcess := slices.Clone(data)
+	binary.LittleEndian.PutUint64(otherProcess[8:], processTag+1)
+	if err := got.UnmarshalBinary(otherProcess); !errors.Is(err, ErrDifferentProcess) {
+		t.Errorf("got error %v, want ErrDifferentProcess", err)
+	}
+}
+
+func TestBloomFilter_WriteTo(t *testing.T) {
+	// Use a filter with more than one chunk of bits.
+	bf := NewBloomFilter[int](1000000, 0.001)
+	if len(bf.bits) <= chunkWords {
+		t.Fatalf("filter has %d words, want more than %d", len(bf.bits), chunkWords)
+	}
+	for i := range 1000 {
+		bf.Add(i)
+	}
+
+	var buf bytes.Buffer
+	n, err := bf.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo returned %d, but wrote %d bytes", n, buf.Len())
+	}
+
+	// Data following the filter shouldn't be consumed.
+	buf.WriteString("trailer")
+
+	var got Filter[int]
+	rn, err := got.ReadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rn != n {
+		t.Errorf("ReadFrom returned %d, want %d", rn, n)
+	}
+	if buf.String() != "trailer" {
+		t.Errorf("got remaining data %q, want %q", buf.String(), "trailer")
+	}
+	if !slices.Equal(got.bits, bf.bits) || !slices.Equal(got.seeds, bf.seeds) {
+		t.Error("filter differs after round-trip")
+	}
+
+	// The chunked format isn't accepted by UnmarshalBinary, and vice versa.
+	var chunked bytes.Buffer
+	bf.WriteTo(&chunked)
+	if err := got.UnmarshalBinary(chunked.Bytes()); err == nil {
+		t.Error("expected error unmarshaling chunked data")
+	}
+	data, _ := bf.MarshalBinary()
+	if _, err := got.ReadFrom(bytes.NewReader(data)); err == nil {
+		t.Error("expected error reading unchunked data")
+	}
+
+	// Truncated input should fail cleanly.
+	if _, err := got.ReadFrom(bytes.NewReader(chunked.Bytes()[:chunked.Len()-1])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got error %v, want io.ErrUnexpectedEOF", err)
+	}
+}
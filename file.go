@@ -0,0 +1,76 @@
+package bloom
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SaveToFile writes the filter, as encoded by [Filter.MarshalBinary], to the
+// file at path. The data is first written to a temporary file in the same
+// directory, which is synced and then renamed over path, so readers observe
+// either the previous contents of path or the complete new filter.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) SaveToFile(path string) (retErr error) {
+	data, err := bf.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+
+	// Sync the directory so the rename itself is durable. Not all platforms
+	// support syncing directories, so this is best-effort.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// LoadFromFile reads a filter previously written by [Filter.SaveToFile].
+//
+// The rest of the filter's configuration is read from the file, so the only
+// options that take effect are those that aren't saved: [WithHooks],
+// [WithSaturationCheck] and [WithIntervalStats]. Others are ignored.
+//
+// Since the hash functions used by a Filter are only consistent within a
+// single process, it returns [ErrDifferentProcess] if the file was written by
+// a different process, unless the filter was created with [WithFNVHash].
+func LoadFromFile[T comparable](path string, opts ...Option) (*Filter[T], error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bf := new(Filter[T])
+	setObservers(bf, makeOptions(opts))
+	if err := bf.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return bf, nil
+}
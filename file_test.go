@@ -0,0 +1,91 @@
+package bloom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBloomFilter_SaveToFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "filter.bloom")
+
+	bf := NewBloomFilter[string](1000, 0.01)
+	bf.Add("apple")
+	if err := bf.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Overwriting an existing file should also work.
+	bf.Add("banana")
+	if err := bf.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadFromFile[string](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Contains("apple") || !got.Contains("banana") {
+		t.Error("loaded filter should contain 'apple' and 'banana'")
+	}
+
+	// No temporary files should be left behind.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d files in directory, want 1", len(entries))
+	}
+}
+
+func TestBloomFilter_SaveToFile_MissingDir(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01)
+	path := filepath.Join(t.TempDir(), "missing", "filter.bloom")
+	if err := bf.SaveToFile(path); err == nil {
+		t.Error("expected error saving to a missing directory")
+	}
+	if _, err := LoadFromFile[string](path); err == nil {
+		t.Error("expected error loading from a missing file")
+	}
+}
+
+func TestLoadFromFile_SaturationCheck(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	for i := range bf.bits {
+		bf.bits[i] = ^uint64(0)
+	}
+	path := filepath.Join(t.TempDir(), "filter.bloom")
+	if err := bf.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var saturated int
+	hooks := WithHooks(Hooks{OnSaturated: func(float64) { saturated++ }})
+	loaded, err := LoadFromFile[int](path, hooks, WithSaturationCheck(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.Contains(1)
+	if saturated != 1 {
+		t.Errorf("got %d calls to OnSaturated for a loaded filter, want 1", saturated)
+	}
+
+	// Decoding into a filter keeps its saturation check and hooks.
+	data, err := bf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	into := NewBloomFilter[int](10, 0.01, hooks, WithSaturationCheck(1), WithIntervalStats())
+	if err := into.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	into.Contains(1)
+	if saturated != 2 {
+		t.Errorf("got %d calls to OnSaturated after decoding, want 2", saturated)
+	}
+	if got := into.SnapshotStats().Contains; got != 1 {
+		t.Errorf("got %d Contains calls counted after decoding, want 1", got)
+	}
+}
@@ -0,0 +1,171 @@
+package bloom
+
+import (
+	"math"
+	"math/bits"
+	"math/rand/v2"
+)
+
+const (
+	// fingerprintSlots is the number of 16-bit fingerprints stored in each
+	// bucket of a FingerprintFilter; a bucket is exactly one uint64.
+	fingerprintSlots = 4
+
+	// fingerprintLoadFactor is the bucket occupancy that a FingerprintFilter
+	// is sized for.
+	fingerprintLoadFactor = 0.95
+
+	// fingerprintMaxKicks is the maximum number of fingerprints that are
+	// relocated while trying to insert a new one.
+	fingerprintMaxKicks = 500
+)
+
+// FingerprintFilter is an alternative to [Filter] that stores a 16-bit
+// fingerprint of each item, rather than setting bits. Each item's fingerprint
+// is stored in one of two candidate buckets of four fingerprints, relocating
+// existing fingerprints between their candidate buckets as necessary (as in a
+// cuckoo filter). Contains reports whether a matching fingerprint is in either
+// of the item's buckets.
+//
+// When filled to capacity, a FingerprintFilter uses roughly 17 bits per item
+// and has a false positive rate of about 0.012%, which is lower than a
+// [Filter] using the same amount of memory. Unlike a Filter its false positive
+// rate can't be configured, and it has a maximum capacity; see
+// [FingerprintFilter.Add]. The number of buckets is rounded up to a power of
+// two, so a filter may use up to twice the memory needed for expectedItems.
+type FingerprintFilter[T comparable] struct {
+	buckets []uint64 // each holds fingerprintSlots 16-bit fingerprints; zero is empty
+	mask    uint64   // len(buckets)-1; len(buckets) is a power of two
+	seed    uint64
+	entries uint
+
+	// victim holds a fingerprint that couldn't be placed once the filter is
+	// full, along with its bucket, so that it isn't lost.
+	victim       uint16
+	victimBucket uint64
+}
+
+// NewFingerprintFilter creates a new, empty FingerprintFilter with capacity
+// for at least expectedItems items.
+func NewFingerprintFilter[T comparable](expectedItems uint) *FingerprintFilter[T] {
+	numBuckets := uint64(math.Ceil(float64(max(expectedItems, 1)) / (fingerprintSlots * fingerprintLoadFactor)))
+	numBuckets = 1 << bits.Len64(numBuckets-1) // round up to a power of two
+
+	return &FingerprintFilter[T]{
+		buckets: make([]uint64, numBuckets),
+		mask:    numBuckets - 1,
+		seed:    rand.Uint64(),
+	}
+}
+
+// Add inserts an item into the filter. It returns false if the filter is full,
+// in which case the item was not added; an item that has been added
+// previously is always reported as added.
+//
+// This method is not safe for concurrent use.
+func (f *FingerprintFilter[T]) Add(item T) bool {
+	fp, i1, i2 := f.locate(item)
+	if f.bucketHas(i1, fp) || f.bucketHas(i2, fp) || (f.victim == fp && (f.victimBucket == i1 || f.victimBucket == i2)) {
+		return true
+	}
+	if f.victim != 0 {
+		return false
+	}
+	f.entries++
+
+	if f.insert(i1, fp) || f.insert(i2, fp) {
+		return true
+	}
+
+	// Both buckets are full; evict a random fingerprint and move it to its
+	// alternate bucket, repeating until we find room.
+	i := i1
+	if rand.IntN(2) == 0 {
+		i = i2
+	}
+	for range fingerprintMaxKicks {
+		slot := uint(rand.IntN(fingerprintSlots))
+		fp = f.swap(i, slot, fp)
+		i = f.altBucket(i, fp)
+		if f.insert(i, fp) {
+			return true
+		}
+	}
+
+	// Keep the last evicted fingerprint so we don't introduce a false
+	// negative; the item we were asked to add is stored.
+	f.victim = fp
+	f.victimBucket = i
+	return true
+}
+
+// Contains tests whether an item might be in the set.
+// False positives are possible, but false negatives are not.
+//
+// This method can be called concurrently with other calls to itself, but not
+// [FingerprintFilter.Add].
+func (f *FingerprintFilter[T]) Contains(item T) bool {
+	fp, i1, i2 := f.locate(item)
+	if f.bucketHas(i1, fp) || f.bucketHas(i2, fp) {
+		return true
+	}
+	return f.victim == fp && (f.victimBucket == i1 || f.victimBucket == i2)
+}
+
+// EstimatedFalsePositiveRate returns the current estimated false positive rate
+// based on the number of items added.
+func (f *FingerprintFilter[T]) EstimatedFalsePositiveRate() float64 {
+	// A query compares against every fingerprint in two buckets, each of
+	// which matches with probability 1/(2^16-1).
+	perBucket := float64(f.entries) / float64(len(f.buckets))
+	return 1 - math.Pow(1-1.0/math.MaxUint16, 2*perBucket)
+}
+
+// locate returns the fingerprint of item and its two candidate buckets.
+func (f *FingerprintFilter[T]) locate(item T) (fp uint16, i1, i2 uint64) {
+	hash := maphashItem(item, f.seed)
+
+	// Use the top bits for the fingerprint and the bottom bits for the
+	// bucket, so that they're independent. Zero marks an empty slot.
+	fp = max(uint16(hash>>48), 1)
+	i1 = hash & f.mask
+	return fp, i1, f.altBucket(i1, fp)
+}
+
+// altBucket returns the other candidate bucket for a fingerprint in bucket i.
+// Since it's an involution, it can be computed from either bucket.
+func (f *FingerprintFilter[T]) altBucket(i uint64, fp uint16) uint64 {
+	return (i ^ (uint64(fp) * 0x5bd1e995)) & f.mask
+}
+
+func (f *FingerprintFilter[T]) bucketHas(i uint64, fp uint16) bool {
+	bucket := f.buckets[i]
+	for slot := range fingerprintSlots {
+		if uint16(bucket>>(16*slot)) == fp {
+			return true
+		}
+	}
+	return false
+}
+
+// insert stores fp in an empty slot of bucket i, returning false if there is
+// none.
+func (f *FingerprintFilter[T]) insert(i uint64, fp uint16) bool {
+	bucket := f.buckets[i]
+	for slot := range fingerprintSlots {
+		if uint16(bucket>>(16*slot)) == 0 {
+			f.buckets[i] = bucket | uint64(fp)<<(16*slot)
+			return true
+		}
+	}
+	return false
+}
+
+// swap replaces the fingerprint in the given slot of bucket i with fp,
+// returning the previous fingerprint.
+func (f *FingerprintFilter[T]) swap(i uint64, slot uint, fp uint16) uint16 {
+	shift := 16 * slot
+	old := uint16(f.buckets[i] >> shift)
+	f.buckets[i] = f.buckets[i]&^(0xffff<<shift) | uint64(fp)<<shift
+	return old
+}
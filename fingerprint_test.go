@@ -0,0 +1,97 @@
+package bloom
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestFingerprintFilter(t *testing.T) {
+	f := NewFingerprintFilter[string](1000)
+
+	for _, fruit := range []string{"apple", "banana", "orange"} {
+		if !f.Add(fruit) {
+			t.Fatalf("failed to add '%s'", fruit)
+		}
+	}
+
+	if !f.Contains("apple") {
+		t.Error("'apple' should be in the filter")
+	}
+	if !f.Contains("banana") {
+		t.Error("'banana' should be in the filter")
+	}
+
+	// Adding a duplicate shouldn't use another slot.
+	f.Add("apple")
+	if got, want := f.entries, uint(3); got != want {
+		t.Errorf("got entries=%d, want %d", got, want)
+	}
+}
+
+func TestFingerprintFilter_Full(t *testing.T) {
+	f := NewFingerprintFilter[int](1000)
+	capacity := len(f.buckets) * fingerprintSlots
+
+	var added []int
+	for i := range 2 * capacity {
+		if !f.Add(i) {
+			break
+		}
+		added = append(added, i)
+	}
+	if len(added) >= 2*capacity {
+		t.Fatalf("expected filter to fill up before %d items", 2*capacity)
+	}
+	if len(added) < int(fingerprintLoadFactor*float64(capacity)) {
+		t.Errorf("filter filled up after only %d of %d slots", len(added), capacity)
+	}
+
+	// Even when full, there must be no false negatives.
+	for _, i := range added {
+		if !f.Contains(i) {
+			t.Fatalf("%d should be in the filter", i)
+		}
+	}
+}
+
+func BenchmarkFingerprintFilterFPR(b *testing.B) {
+	const probes = 1000000
+
+	// Fill the filter to its designed load.
+	f := NewFingerprintFilter[int](100000)
+	n := int(fingerprintLoadFactor * float64(len(f.buckets)*fingerprintSlots))
+	for i := range n {
+		f.Add(i)
+	}
+
+	// A classic filter using the same amount of memory, with the optimal
+	// number of hash functions for that size.
+	m := uint(len(f.buckets) * 64)
+	k := uint(math.Round(float64(m) / float64(n) * math.Ln2))
+	seeds := make([]uint64, k)
+	for i := range seeds {
+		seeds[i] = rand.Uint64()
+	}
+	bf, err := NewBloomFilterWithSeeds[int](m, k, seeds)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := range n {
+		bf.Add(i)
+	}
+
+	for b.Loop() {
+		var fingerprintFPs, bloomFPs int
+		for i := n; i < n+probes; i++ {
+			if f.Contains(i) {
+				fingerprintFPs++
+			}
+			if bf.Contains(i) {
+				bloomFPs++
+			}
+		}
+		b.ReportMetric(float64(fingerprintFPs)/probes, "fingerprint-fpr")
+		b.ReportMetric(float64(bloomFPs)/probes, "bloom-fpr")
+	}
+}
@@ -0,0 +1,163 @@
+package bloom
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// The FNV-1a parameters for 64-bit hashes; see:
+//
+//	http://www.isthe.com/chongo/tech/comp/fnv/
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// fnvSeeds returns the deterministic seeds used for k hash functions when
+// hashing with [WithFNVHash]. The i-th hash function's seed is derived from i
+// alone, so that any two such filters with the same k are compatible.
+func fnvSeeds(k uint) []uint64 {
+	seeds := make([]uint64, k)
+	for i := range seeds {
+		seeds[i] = mix64(uint64(i) + 1)
+	}
+	return seeds
+}
+
+// fnvHash hashes item with FNV-1a, starting from an offset basis derived from
+// seed.
+func fnvHash[T comparable](item T, seed uint64) uint64 {
+	h := fnvHasher(fnvOffset64 ^ seed)
+	switch v := any(item).(type) {
+	case string:
+		h.writeString(v)
+	case int:
+		h.writeUint(uint64(v), 8)
+	case int64:
+		h.writeUint(uint64(v), 8)
+	case uint:
+		h.writeUint(uint64(v), 8)
+	case uint64:
+		h.writeUint(v, 8)
+	case int32:
+		h.writeUint(uint64(v), 4)
+	case uint32:
+		h.writeUint(uint64(v), 4)
+	default:
+		h.writeValue(reflect.ValueOf(item))
+	}
+
+	// FNV-1a mixes its final bytes poorly, so apply a finalizer before the
+	// hash is reduced to a bit position.
+	return mix64(uint64(h))
+}
+
+// fnvHasher is the state of an FNV-1a hash. It encodes a comparable value as
+// a canonical, platform-independent sequence of bytes, so that equal values
+// always have equal hashes, in any process.
+type fnvHasher uint64
+
+func (h *fnvHasher) writeByte(b byte) {
+	*h = (*h ^ fnvHasher(b)) * fnvPrime64
+}
+
+// writeUint writes the low size bytes of v, in little-endian order.
+func (h *fnvHasher) writeUint(v uint64, size int) {
+	for range size {
+		h.writeByte(byte(v))
+		v >>= 8
+	}
+}
+
+func (h *fnvHasher) writeString(s string) {
+	// Prefix the length, so that e.g. the structs {"ab", "c"} and
+	// {"a", "bc"} are encoded differently.
+	h.writeUint(uint64(len(s)), 8)
+	for i := range len(s) {
+		h.writeByte(s[i])
+	}
+}
+
+func (h *fnvHasher) writeFloat(f float64, size int) {
+	if f == 0 {
+		f = 0 // -0 == +0, so they must hash the same
+	}
+	if size == 4 {
+		h.writeUint(uint64(math.Float32bits(float32(f))), 4)
+	} else {
+		h.writeUint(math.Float64bits(f), 8)
+	}
+}
+
+func (h *fnvHasher) writeValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			h.writeByte(1)
+		} else {
+			h.writeByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		h.writeUint(uint64(v.Int()), intSize(v.Type()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		h.writeUint(v.Uint(), intSize(v.Type()))
+	case reflect.Float32, reflect.Float64:
+		h.writeFloat(v.Float(), int(v.Type().Size()))
+	case reflect.Complex64, reflect.Complex128:
+		size := int(v.Type().Size()) / 2
+		h.writeFloat(real(v.Complex()), size)
+		h.writeFloat(imag(v.Complex()), size)
+	case reflect.String:
+		h.writeString(v.String())
+	case reflect.Array:
+		for i := range v.Len() {
+			h.writeValue(v.Index(i))
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).Name == "_" {
+				continue // blank fields are ignored by ==
+			}
+			h.writeValue(v.Field(i))
+		}
+	default:
+		// Rejected by checkFNVType at construction.
+		panic("bloom: unsupported type for FNV hashing: " + v.Type().String())
+	}
+}
+
+// intSize returns the number of bytes used to encode an integer of type t.
+// Platform-dependent integer types always use 8 bytes.
+func intSize(t reflect.Type) int {
+	switch t.Kind() {
+	case reflect.Int, reflect.Uint, reflect.Uintptr:
+		return 8
+	}
+	return int(t.Size())
+}
+
+// checkFNVType returns an error if values of type t can't be hashed
+// deterministically by fnvHash. Pointers, channels and interfaces are
+// rejected, since their hashes would depend on memory addresses or dynamic
+// types that aren't stable across processes.
+func checkFNVType(t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128,
+		reflect.String:
+		return nil
+	case reflect.Array:
+		return checkFNVType(t.Elem())
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if err := checkFNVType(t.Field(i).Type); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("bloom: type %v can't be hashed with FNV", t)
+}
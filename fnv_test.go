@@ -0,0 +1,117 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestWithFNVHash(t *testing.T) {
+	a := NewBloomFilter[string](1000, 0.01, WithFNVHash())
+	b := NewBloomFilter[string](1000, 0.01, WithFNVHash())
+	if a.ConfigHash() != b.ConfigHash() {
+		t.Error("FNV filters with the same parameters should be compatible")
+	}
+
+	for _, fruit := range []string{"apple", "banana", "orange"} {
+		a.Add(fruit)
+	}
+	for _, fruit := range []string{"apple", "banana", "orange"} {
+		if !a.Contains(fruit) {
+			t.Errorf("'%s' should be in the filter", fruit)
+		}
+	}
+
+	// Hash positions must be stable across processes and Go versions.
+	want := []uint{8005, 5519, 2312, 67, 6710, 5511, 7702}
+	got := a.HashPositions("apple")
+	if len(got) != len(want) {
+		t.Fatalf("got %d positions, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got positions %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestWithFNVHash_Serialization(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01, WithFNVHash())
+	bf.Add("apple")
+
+	data, err := bf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// FNV filters don't depend on the process, so they don't record it.
+	if tag := binary.LittleEndian.Uint64(data[8:]); tag != 0 {
+		t.Errorf("got process tag %x, want 0", tag)
+	}
+
+	var got Filter[string]
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Contains("apple") {
+		t.Error("'apple' should be in the decoded filter")
+	}
+}
+
+func TestFNVHash_Stable(t *testing.T) {
+	// Serialized FNV filters must stay valid across releases, so these
+	// hashes must never change.
+	type point struct {
+		X, Y int32
+		Name string
+	}
+	seed := fnvSeeds(1)[0]
+	tests := []struct {
+		got, want uint64
+	}{
+		{fnvHash("apple", seed), 0xd5ca10306e95b9c0},
+		{fnvHash(42, seed), 0x9c430eb5f06b5204},
+		{fnvHash(point{1, -2, "a"}, seed), 0xbe5996fceb27223c},
+		{fnvHash(1.5, seed), 0x2907c1ffaea0ef38},
+	}
+	for i, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("hash %d: got %#x, want %#x", i, tt.got, tt.want)
+		}
+	}
+}
+
+func TestFNVHash_Types(t *testing.T) {
+	type myInt int
+	type point struct {
+		X, Y int32
+		_    int
+		Name string
+	}
+
+	if fnvHash(42, 1) != fnvHash(myInt(42), 1) {
+		t.Error("named types should hash the same as their underlying type")
+	}
+	if fnvHash(0.0, 1) != fnvHash(math.Copysign(0, -1), 1) {
+		t.Error("-0 and +0 should hash the same")
+	}
+
+	p := point{X: 1, Y: 2, Name: "a"}
+	if fnvHash(p, 1) != fnvHash(point{X: 1, Y: 2, Name: "a"}, 1) {
+		t.Error("equal structs should hash the same")
+	}
+	if fnvHash(p, 1) == fnvHash(point{X: 2, Y: 1, Name: "a"}, 1) {
+		t.Error("different structs should hash differently")
+	}
+	if fnvHash([2]string{"ab", "c"}, 1) == fnvHash([2]string{"a", "bc"}, 1) {
+		t.Error("string boundaries should affect the hash")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for pointer type")
+		}
+	}()
+	NewBloomFilter[*int](1000, 0.01, WithFNVHash())
+}
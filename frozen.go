@@ -0,0 +1,62 @@
+package bloom
+
+import "io"
+
+// FrozenFilter is an immutable Bloom filter, for serving queries against a
+// filter that was built elsewhere, such as on a read replica. Since it can't
+// be modified, all of its methods are safe for concurrent use.
+type FrozenFilter[T comparable] struct {
+	filter *Filter[T]
+}
+
+// Freeze returns an immutable copy of bf.
+func (bf *Filter[T]) Freeze() *FrozenFilter[T] {
+	frozen := bf.emptyCopy()
+	copy(frozen.bits, bf.bits)
+	frozen.entries = bf.entries
+	return &FrozenFilter[T]{filter: frozen}
+}
+
+// LoadReadOnly reads a filter written by [Filter.WriteTo] from r, like
+// [Filter.ReadFrom], and returns it as a FrozenFilter. As with
+// [LoadFromFile], only the options that aren't encoded take effect.
+func LoadReadOnly[T comparable](r io.Reader, opts ...Option) (*FrozenFilter[T], error) {
+	bf := new(Filter[T])
+	setObservers(bf, makeOptions(opts))
+	if _, err := bf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return &FrozenFilter[T]{filter: bf}, nil
+}
+
+// Contains tests whether an item might be in the set.
+// False positives are possible, but false negatives are not.
+func (ff *FrozenFilter[T]) Contains(item T) bool {
+	return ff.filter.Contains(item)
+}
+
+// ContainsMany is like [Filter.ContainsMany].
+func (ff *FrozenFilter[T]) ContainsMany(items []T) []uint64 {
+	return ff.filter.ContainsMany(items)
+}
+
+// ContainsBatchParallel is like [Filter.ContainsBatchParallel].
+func (ff *FrozenFilter[T]) ContainsBatchParallel(items []T, workers int) []bool {
+	return ff.filter.ContainsBatchParallel(items, workers)
+}
+
+// Entries returns the number of items added to the filter before it was
+// frozen, or zero if the filter didn't count them.
+func (ff *FrozenFilter[T]) Entries() uint {
+	return ff.filter.entries
+}
+
+// EstimatedFalsePositiveRate is like [Filter.EstimatedFalsePositiveRate].
+func (ff *FrozenFilter[T]) EstimatedFalsePositiveRate() float64 {
+	return ff.filter.EstimatedFalsePositiveRate()
+}
+
+// WriteTo implements [io.WriterTo], like [Filter.WriteTo].
+func (ff *FrozenFilter[T]) WriteTo(w io.Writer) (int64, error) {
+	return ff.filter.WriteTo(w)
+}
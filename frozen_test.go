@@ -0,0 +1,66 @@
+package bloom
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestLoadReadOnly(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	for i := range 1000 {
+		bf.Add(i)
+	}
+	var buf bytes.Buffer
+	if _, err := bf.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	ff, err := LoadReadOnly[int](&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ff.Entries(), uint(1000); got != want {
+		t.Errorf("got %d entries, want %d", got, want)
+	}
+	if got, want := ff.EstimatedFalsePositiveRate(), bf.EstimatedFalsePositiveRate(); got != want {
+		t.Errorf("got estimated FPR %v, want %v", got, want)
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				if !ff.Contains(i) {
+					t.Errorf("%d should be in the filter", i)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := LoadReadOnly[int](bytes.NewReader([]byte("BLMF"))); err == nil {
+		t.Error("expected error for truncated data")
+	}
+}
+
+func TestBloomFilter_Freeze(t *testing.T) {
+	bf := NewBloomFilter[string](1000, 0.01, WithFNVHash())
+	bf.Add("apple")
+	ff := bf.Freeze()
+
+	// Later changes to the filter don't affect the frozen copy.
+	bf.Add("banana")
+	if !ff.Contains("apple") {
+		t.Error("'apple' should be in the frozen filter")
+	}
+	if ff.Contains("banana") {
+		t.Error("'banana' was added after freezing")
+	}
+	if got, want := ff.Entries(), uint(1); got != want {
+		t.Errorf("got %d entries, want %d", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package bloom
+
+// GrowableFilter is a Bloom filter backed by a single bit array that is
+// rebuilt at twice the size whenever it exceeds its capacity. Since a Bloom
+// filter can't enumerate its items, rebuilding relies on a caller-supplied
+// function that returns the current set of items, which suits workloads where
+// the source set is cheap to re-enumerate.
+//
+// Unlike a [ScalableFilter], which grows by adding stages, every query
+// against a GrowableFilter checks a single array, and its false positive rate
+// returns to the target after each rebuild.
+type GrowableFilter[T comparable] struct {
+	filter   *Filter[T]
+	capacity uint
+	fpr      float64
+	reload   func() []T
+}
+
+// NewGrowableFilter creates a new, empty GrowableFilter sized for initial
+// items at the desired false positive rate. When more than its current
+// capacity of items have been added, the filter doubles its capacity and
+// re-adds every item returned by reload.
+//
+// It panics under the same conditions as [NewBloomFilter].
+func NewGrowableFilter[T comparable](initial uint, falsePositiveRate float64, reload func() []T) *GrowableFilter[T] {
+	initial = max(initial, 1)
+	return &GrowableFilter[T]{
+		filter:   NewBloomFilter[T](initial, falsePositiveRate),
+		capacity: initial,
+		fpr:      falsePositiveRate,
+		reload:   reload,
+	}
+}
+
+// Add inserts an item into the filter, growing it first if it is at capacity.
+// The item is added after the rebuild, so reload need not already include it.
+//
+// This method is not safe for concurrent use.
+func (g *GrowableFilter[T]) Add(item T) {
+	if g.filter.entries >= g.capacity {
+		g.grow()
+	}
+	g.filter.Add(item)
+}
+
+// grow rebuilds the filter at twice its current capacity.
+func (g *GrowableFilter[T]) grow() {
+	items := g.reload()
+
+	// Make sure the new filter has room for everything it's about to hold.
+	capacity := g.capacity * 2
+	for capacity <= uint(len(items)) {
+		capacity *= 2
+	}
+
+	g.filter = NewBloomFilter[T](capacity, g.fpr)
+	g.capacity = capacity
+	for _, item := range items {
+		g.filter.Add(item)
+	}
+}
+
+// Contains tests whether an item might be in the set.
+// False positives are possible, but false negatives are not, provided that
+// reload returns every item added to the filter before each rebuild.
+//
+// This method can be called concurrently with other calls to itself, but not
+// [GrowableFilter.Add].
+func (g *GrowableFilter[T]) Contains(item T) bool {
+	return g.filter.Contains(item)
+}
+
+// Capacity returns the number of items the filter can hold before it next
+// grows.
+func (g *GrowableFilter[T]) Capacity() uint {
+	return g.capacity
+}
+
+// EstimatedFalsePositiveRate returns the current estimated false positive rate
+// of the underlying filter.
+func (g *GrowableFilter[T]) EstimatedFalsePositiveRate() float64 {
+	return g.filter.EstimatedFalsePositiveRate()
+}
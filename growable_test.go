@@ -0,0 +1,34 @@
+package bloom
+
+import (
+	"testing"
+)
+
+func TestGrowableFilter(t *testing.T) {
+	var source []int
+	reloads := 0
+	g := NewGrowableFilter(100, 0.01, func() []int {
+		reloads++
+		return source
+	})
+
+	for i := range 1000 {
+		source = append(source, i)
+		g.Add(i)
+	}
+
+	if reloads != 4 { // 100 -> 200 -> 400 -> 800 -> 1600
+		t.Errorf("got %d reloads, want 4", reloads)
+	}
+	if got, want := g.Capacity(), uint(1600); got != want {
+		t.Errorf("got capacity %d, want %d", got, want)
+	}
+	for i := range 1000 {
+		if !g.Contains(i) {
+			t.Fatalf("%d should be in the filter", i)
+		}
+	}
+	if fpr := g.EstimatedFalsePositiveRate(); fpr > 0.01 {
+		t.Errorf("got estimated FPR %v, want <= 0.01", fpr)
+	}
+}
@@ -0,0 +1,119 @@
+package bloom
+
+import (
+	"errors"
+	"math"
+	"slices"
+)
+
+// ErrIncompatible is the error matched by errors.Is for every
+// [*IncompatibleError].
+var ErrIncompatible = errors.New("bloom: incompatible filters")
+
+// IncompatibleError is returned by operations that combine two filters, such
+// as [Filter.Union], when the filters' configurations differ.
+type IncompatibleError struct {
+	// Param names the configuration that differs between the filters: "m"
+	// for the size of the bit array, "k" for the number of hash functions,
+	// "hash" for the hashing scheme, such as maphash or FNV-1a, "seeds" for
+	// the hash functions' seeds, "counter bits" for the size of a
+	// [CountingFilter]'s counters, or "config" when only the filters'
+	// [Filter.ConfigHash] values are known to differ, such as for a
+	// [Delta].
+	Param string
+}
+
+func (e *IncompatibleError) Error() string {
+	return "bloom: incompatible filters: different " + e.Param
+}
+
+// Is reports whether target is [ErrIncompatible].
+func (e *IncompatibleError) Is(target error) bool {
+	return target == ErrIncompatible
+}
+
+// checkCompatible returns an [*IncompatibleError] if bf and other can't be
+// combined.
+func (bf *Filter[T]) checkCompatible(other *Filter[T]) error {
+	switch {
+	case bf.m != other.m:
+		return &IncompatibleError{Param: "m"}
+	case len(bf.seeds) != len(other.seeds):
+		return &IncompatibleError{Param: "k"}
+	case bf.fnv != other.fnv:
+		return &IncompatibleError{Param: "hash"}
+	case !slices.Equal(bf.seeds, other.seeds):
+		return &IncompatibleError{Param: "seeds"}
+	}
+	return nil
+}
+
+// Union adds every item in other to bf, by setting each bit in bf that is set
+// in other. The filters must have the same configuration, such as when one
+// was created by [NewBloomFilterWithSeeds] using the other's seeds; otherwise
+// Union returns an [*IncompatibleError] and leaves bf unchanged.
+//
+// The entry count of bf becomes the sum of both filters' entry counts, which
+// overestimates the number of items if the filters have items in common, such
+// as when one is a [Filter.Clone] of the other; see [Filter.UnionDistinct].
+//
+// This method is not safe for concurrent use with other methods on bf.
+func (bf *Filter[T]) Union(other *Filter[T]) error {
+	if err := bf.checkCompatible(other); err != nil {
+		return err
+	}
+	for i, word := range other.bits {
+		bf.bits[i] |= word
+	}
+	if !bf.noEntries {
+		bf.entries += other.entries
+	}
+	return nil
+}
+
+// UnionDistinct is like [Filter.Union], but sets the entry count of bf to the
+// [Filter.EstimatedCardinality] of the combined bits, rather than the sum of
+// both filters' entry counts. The estimate counts items held by both filters
+// once, so it suits filters with items in common, such as a filter and its
+// [Filter.Clone]. If every bit is set, so that there is no estimate, the entry
+// count becomes the sum as for Union.
+//
+// This method is not safe for concurrent use with other methods on bf.
+func (bf *Filter[T]) UnionDistinct(other *Filter[T]) error {
+	if err := bf.Union(other); err != nil {
+		return err
+	}
+	if n := bf.EstimatedCardinality(); !bf.noEntries && !math.IsInf(n, 0) {
+		bf.entries = uint(math.Round(n))
+	}
+	return nil
+}
+
+// UnionRaw adds the items represented by a raw bit array, such as one
+// returned by [Filter.Bits] for a filter of a different element type, to bf,
+// by setting each bit in bf that is set in otherBits. The entry count of bf is
+// increased by otherEntries.
+//
+// The caller must ensure that the bits were set using exactly the same bit
+// positions as bf would use for the same items: the same size of bit array,
+// hashing scheme, and seeds, and an encoding of items in which equal items
+// hash equally for both element types. This can't be checked, so a mismatch
+// silently makes bf report arbitrary results. The only check made is that
+// otherBits has the same number of words as bf; otherwise UnionRaw returns an
+// [*IncompatibleError] and leaves bf unchanged. Bits at or beyond
+// [Filter.BitsLen] are ignored.
+//
+// This method is not safe for concurrent use with other methods on bf.
+func (bf *Filter[T]) UnionRaw(otherBits []uint64, otherEntries uint) error {
+	if len(otherBits) != len(bf.bits) {
+		return &IncompatibleError{Param: "m"}
+	}
+	for i, word := range otherBits {
+		bf.bits[i] |= word
+	}
+	bf.bits[len(bf.bits)-1] &= bf.tailMask()
+	if !bf.noEntries {
+		bf.entries += otherEntries
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package bloom
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBloomFilter_Union(t *testing.T) {
+	a := NewBloomFilter[string](1000, 0.01)
+	b, err := NewBloomFilterWithSeeds[string](a.m, uint(len(a.seeds)), a.Seeds())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a.Add("apple")
+	b.Add("banana")
+	if err := a.Union(b); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Contains("apple") || !a.Contains("banana") {
+		t.Error("union should contain 'apple' and 'banana'")
+	}
+	if got, want := a.entries, uint(2); got != want {
+		t.Errorf("got entries=%d, want %d", got, want)
+	}
+}
+
+func TestBloomFilter_UnionDistinct(t *testing.T) {
+	a := NewBloomFilter[int](10000, 0.01)
+	for i := range 1000 {
+		a.Add(i)
+	}
+	b := a.Clone()
+	for i := 1000; i < 2000; i++ {
+		b.Add(i)
+	}
+
+	// a's items are counted by both filters, but only once by the union.
+	if err := a.UnionDistinct(b); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.entries; got < 1900 || got > 2100 {
+		t.Errorf("got entries=%d, want about 2000", got)
+	}
+
+	other := NewBloomFilter[int](10000, 0.01)
+	if err := a.UnionDistinct(other); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("got error %v, want ErrIncompatible", err)
+	}
+}
+
+func TestBloomFilter_Union_Incompatible(t *testing.T) {
+	a := NewBloomFilter[string](1000, 0.01)
+	seeds := a.Seeds()
+	k := uint(len(seeds))
+
+	otherM, _ := NewBloomFilterWithSeeds[string](a.m+1, k, seeds)
+	otherK, _ := NewBloomFilterWithSeeds[string](a.m, k+1, append(seeds, 1))
+	otherSeeds := NewBloomFilter[string](1000, 0.01)
+	otherHash, _ := NewBloomFilterWithSeeds[string](a.m, k, seeds, WithFNVHash())
+
+	tests := []struct {
+		other *Filter[string]
+		param string
+	}{
+		{otherM, "m"},
+		{otherK, "k"},
+		{otherHash, "hash"},
+		{otherSeeds, "seeds"},
+	}
+	for _, tt := range tests {
+		err := a.Union(tt.other)
+		if !errors.Is(err, ErrIncompatible) {
+			t.Errorf("got error %v, want ErrIncompatible", err)
+		}
+		var ie *IncompatibleError
+		if !errors.As(err, &ie) || ie.Param != tt.param {
+			t.Errorf("got error %v, want IncompatibleError for %q", err, tt.param)
+		}
+	}
+}
+
+func TestBloomFilter_UnionRaw(t *testing.T) {
+	// Filters of strings and of their sums, with the same configuration,
+	// set the same bits for the same items under AddSum.
+	strs := NewBloomFilter[string](1000, 0.01)
+	sums, err := NewBloomFilterWithSeeds[uint64](strs.m, uint(len(strs.seeds)), strs.Seeds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	strs.AddSum(1)
+	sums.AddSum(2)
+	sums.AddSum(3)
+
+	if err := strs.UnionRaw(sums.Bits(), sums.entries); err != nil {
+		t.Fatal(err)
+	}
+	for _, sum := range []uint64{1, 2, 3} {
+		if !strs.ContainsSum(sum) {
+			t.Errorf("sum %d should be in the filter", sum)
+		}
+	}
+	if got, want := strs.entries, uint(3); got != want {
+		t.Errorf("got %d entries, want %d", got, want)
+	}
+
+	// Bits beyond m are ignored.
+	raw := make([]uint64, len(strs.bits))
+	raw[len(raw)-1] = ^uint64(0)
+	if err := strs.UnionRaw(raw, 0); err != nil {
+		t.Fatal(err)
+	}
+	if strs.bits[len(strs.bits)-1]&^strs.tailMask() != 0 {
+		t.Error("bits beyond m should not be set")
+	}
+
+	var ie *IncompatibleError
+	if err := strs.UnionRaw(raw[1:], 0); !errors.As(err, &ie) || ie.Param != "m" {
+		t.Errorf("got error %v, want IncompatibleError for m", err)
+	}
+}
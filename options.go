@@ -0,0 +1,156 @@
+package bloom
+
+// An Option configures optional behaviour of a [Filter] at construction time.
+type Option func(*options)
+
+// options holds the configuration set by a list of [Option] values.
+type options struct {
+	noEntryCounting bool
+	fnvHash         bool
+	domain          string
+	hooks           Hooks
+
+	saturationInterval uint
+	intervalStats      bool
+}
+
+func makeOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// newSeeds returns seeds for k hash functions.
+func (o options) newSeeds(k uint) []uint64 {
+	if o.fnvHash {
+		return fnvSeeds(k)
+	}
+	return randomSeeds(k)
+}
+
+// applyDomain mixes the configured domain, if any, into seeds in place.
+func (o options) applyDomain(seeds []uint64) {
+	if o.domain == "" {
+		return
+	}
+	h := fnvHasher(fnvOffset64)
+	h.writeString(o.domain)
+	d := mix64(uint64(h))
+	for i := range seeds {
+		seeds[i] = mix64(seeds[i] ^ d)
+	}
+}
+
+// WithoutEntryCounting disables tracking of the number of items added to the
+// filter, removing a small amount of work from [Filter.Add].
+//
+// Without an entry count, [Filter.EstimatedFalsePositiveRate] instead
+// estimates the false positive rate from the number of set bits in the
+// filter, which also remains accurate when the same item is added repeatedly.
+func WithoutEntryCounting() Option {
+	return func(o *options) {
+		o.noEntryCounting = true
+	}
+}
+
+// WithFNVHash makes the filter hash items with FNV-1a, rather than the default
+// of [hash/maphash]. With FNV-1a, hashing is fully deterministic: filters with
+// the same size and number of hash functions hash items identically in any
+// process, so they can be serialized and compared across processes. The hashes
+// are computed by this package rather than the runtime, and are part of its
+// compatibility guarantee: they don't change between Go releases or versions
+// of this package, so serialized filters remain valid across upgrades.
+//
+// FNV-1a hashes each item's canonical encoding byte by byte, using reflection
+// for types other than strings and common integer types, which makes it
+// considerably slower than maphash for large filters or items. Only types
+// built from booleans, numbers and strings, including arrays and structs of
+// them, are supported; constructing a filter for any other type with this
+// option panics.
+func WithFNVHash() Option {
+	return func(o *options) {
+		o.fnvHash = true
+	}
+}
+
+// WithDomain separates the filter's hash functions by domain, so that filters
+// with different domains set uncorrelated bits for the same items, even when
+// they are created with the same seeds, such as with [WithFNVHash] or
+// [NewBloomFilterWithSeeds]. An empty domain is the same as no domain.
+//
+// The domain is mixed into the filter's seeds when it is created, so
+// [Filter.Seeds] returns seeds that already include it; pass them to
+// [NewBloomFilterWithSeeds] without WithDomain to recreate the filter.
+func WithDomain(domain string) Option {
+	return func(o *options) {
+		o.domain = domain
+	}
+}
+
+// Hooks are functions that a [Filter] calls as it is used, such as to record
+// metrics. Any of them may be nil.
+type Hooks struct {
+	// OnAdd is called by each call to [Filter.Add], including those made
+	// by other methods that add items.
+	OnAdd func()
+
+	// OnContains is called by each call to [Filter.Contains], including
+	// those made by [Filter.CountPresent], [Filter.ContainsMany] and
+	// [Filter.ContainsProbability], and to [Filter.ContainsSum] and
+	// [Query.In], with its result. It isn't called for the probes made by
+	// [Filter.MeasureFalsePositiveRate].
+	OnContains func(found bool)
+
+	// OnSaturated is called when a check enabled by
+	// [WithSaturationCheck] finds that more than 70% of the filter's bits
+	// are set, the same threshold used by [Filter.Health], with the
+	// filter's fill ratio.
+	OnSaturated func(fillRatio float64)
+}
+
+// WithHooks makes the filter call hooks as it is used. The hooks are called
+// synchronously, so they should be fast; OnContains must be safe to call
+// concurrently if Contains is. Hooks are not serialized: a filter decoded with
+// [Filter.UnmarshalBinary] or [Filter.ReadFrom] keeps its own hooks, and
+// [LoadFromFile] and [LoadReadOnly] accept WithHooks.
+//
+// A filter without hooks only pays for a nil check in each call.
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) {
+		o.hooks = hooks
+	}
+}
+
+// WithSaturationCheck makes the filter check its [Filter.FillRatio] once in
+// every interval calls to [Filter.Add] or [Filter.Contains], calling the
+// [Hooks.OnSaturated] hook set by [WithHooks] for each check that finds it
+// saturated. Each item added or queried by other methods, such as
+// [Filter.ContainsMany] and [Query.In], counts as a call. This detects saturation in processes that only query a filter,
+// such as one loaded from disk with [LoadFromFile], which accepts this option.
+// Each check takes time proportional to the size of the filter, so interval
+// should be large enough to amortize it; a filter without a saturation check
+// pays only for a nil check in each call.
+//
+// The check is made by whichever call reaches the interval, so OnSaturated
+// must be safe to call concurrently if Contains is. An interval of zero
+// disables the check.
+func WithSaturationCheck(interval uint) Option {
+	return func(o *options) {
+		o.saturationInterval = interval
+	}
+}
+
+// WithIntervalStats makes the filter count calls to [Filter.Add] and
+// [Filter.Contains], for reading and resetting with [Filter.SnapshotStats],
+// such as to export rates from a metrics loop. As with [WithSaturationCheck],
+// each item added or queried by other methods, such as [Filter.ContainsMany]
+// and [Query.In], counts as a call. The counters are updated atomically, so
+// concurrent calls to Contains contend on them; a filter without interval
+// stats pays only for a nil check in each call.
+func WithIntervalStats() Option {
+	return func(o *options) {
+		o.intervalStats = true
+	}
+}
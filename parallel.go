@@ -0,0 +1,117 @@
+package bloom
+
+import (
+	"runtime"
+	"sync"
+)
+
+// BuildParallel creates a new Bloom filter sized for expectedItems at the
+// desired false positive rate, and adds every item in items, using up to
+// workers goroutines. Each goroutine adds a share of the items to its own
+// filter with the same hash functions, and the results are combined with
+// [Filter.Union], so the result is identical to adding every item to a single
+// filter in turn. If workers is zero or negative, it uses
+// [runtime.GOMAXPROCS] goroutines.
+//
+// Each additional goroutine allocates a bit array the size of the result. Any
+// [Hooks.OnAdd] hook is called from each goroutine, so it must be safe for
+// concurrent use, as must [Hooks.OnSaturated] if the filter has a saturation
+// check. The calls made by every goroutine are counted by the result's
+// [WithIntervalStats] counters.
+//
+// It panics under the same conditions as [NewBloomFilter].
+func BuildParallel[T comparable](items []T, expectedItems uint, falsePositiveRate float64, workers int, opts ...Option) *Filter[T] {
+	bf := NewBloomFilter[T](expectedItems, falsePositiveRate, opts...)
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = max(min(workers, len(items)), 1)
+
+	// The first share is added to bf directly.
+	shares := make([]*Filter[T], workers)
+	shares[0] = bf
+	for i := 1; i < workers; i++ {
+		shares[i] = bf.emptyCopy()
+	}
+
+	var wg sync.WaitGroup
+	for i, share := range shares {
+		lo, hi := i*len(items)/workers, (i+1)*len(items)/workers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, item := range items[lo:hi] {
+				share.Add(item)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, share := range shares[1:] {
+		// Can't fail, since every share has bf's configuration.
+		_ = bf.Union(share)
+		bf.addCounters(share)
+	}
+	return bf
+}
+
+// ContainsBatchParallel tests each of the provided items for membership, like
+// [Filter.Contains], using up to workers goroutines that each query a share of
+// the items. The result holds whether each item might be in the set, in the
+// same order as items. If workers is zero or negative, it uses
+// [runtime.GOMAXPROCS] goroutines.
+//
+// Each goroutine costs about as much to start as a few queries, so this only
+// pays off for batches large enough to give each goroutine many items. Any
+// [Hooks.OnContains] hook is called from each goroutine, so it must be safe
+// for concurrent use.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) ContainsBatchParallel(items []T, workers int) []bool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = max(min(workers, len(items)), 1)
+
+	result := make([]bool, len(items))
+	var wg sync.WaitGroup
+	for i := range workers {
+		lo, hi := i*len(items)/workers, (i+1)*len(items)/workers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j, item := range items[lo:hi] {
+				result[lo+j] = bf.Contains(item)
+			}
+		}()
+	}
+	wg.Wait()
+	return result
+}
+
+// emptyCopy returns a new, empty filter with the same configuration as bf.
+// The copy has its own saturation check and interval counters, if bf has
+// them, starting from zero.
+func (bf *Filter[T]) emptyCopy() *Filter[T] {
+	empty := &Filter[T]{
+		bits:      make([]uint64, len(bf.bits)),
+		m:         bf.m,
+		seeds:     bf.Seeds(),
+		noEntries: bf.noEntries,
+		fnv:       bf.fnv,
+		distinct:  bf.distinct,
+		domain:    bf.domain,
+		hooks:     bf.hooks,
+
+		expectedItems: bf.expectedItems,
+		targetFPR:     bf.targetFPR,
+	}
+	if bf.saturation != nil {
+		empty.saturation = &saturationCheck{interval: bf.saturation.interval}
+	}
+	if bf.interval != nil {
+		empty.interval = new(intervalCounters)
+	}
+	return empty
+}
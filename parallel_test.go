@@ -0,0 +1,100 @@
+package bloom
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestBuildParallel(t *testing.T) {
+	items := make([]int, 10000)
+	for i := range items {
+		items[i] = i
+	}
+
+	for _, workers := range []int{0, 1, 3, 8} {
+		bf := BuildParallel(items, 10000, 0.01, workers)
+
+		serial, err := NewBloomFilterWithSeeds[int](bf.m, uint(len(bf.seeds)), bf.Seeds())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range items {
+			serial.Add(item)
+		}
+
+		if !slices.Equal(bf.bits, serial.bits) {
+			t.Errorf("workers=%d: parallel build should match serial build", workers)
+		}
+		if got, want := bf.entries, uint(len(items)); got != want {
+			t.Errorf("workers=%d: got %d entries, want %d", workers, got, want)
+		}
+	}
+
+	if bf := BuildParallel([]int(nil), 100, 0.01, 4); bf.entries != 0 {
+		t.Errorf("got %d entries for no items, want 0", bf.entries)
+	}
+}
+
+func TestBuildParallel_Options(t *testing.T) {
+	items := make([]int, 10000)
+	for i := range items {
+		items[i] = i
+	}
+
+	bf := BuildParallel(items, 10000, 0.01, 4, WithIntervalStats(), WithSaturationCheck(1000))
+	if bf.saturation == nil || bf.saturation.interval != 1000 {
+		t.Error("built filter should keep its saturation check")
+	}
+	if got, want := bf.saturation.calls.Load(), uint64(len(items)); got != want {
+		t.Errorf("got %d saturation check calls, want %d", got, want)
+	}
+	if got, want := bf.SnapshotStats().Adds, uint64(len(items)); got != want {
+		t.Errorf("got %d adds, want %d", got, want)
+	}
+}
+
+func TestBloomFilter_ContainsBatchParallel(t *testing.T) {
+	bf := NewBloomFilter[int](10000, 0.01)
+	for i := range 10000 {
+		bf.Add(i)
+	}
+	items := make([]int, 20000)
+	for i := range items {
+		items[i] = i * 7
+	}
+
+	serial := make([]bool, len(items))
+	for i, item := range items {
+		serial[i] = bf.Contains(item)
+	}
+	for _, workers := range []int{0, 1, 3, 8} {
+		if got := bf.ContainsBatchParallel(items, workers); !slices.Equal(got, serial) {
+			t.Errorf("workers=%d: parallel results should match serial results", workers)
+		}
+	}
+
+	if got := bf.ContainsBatchParallel(nil, 4); len(got) != 0 {
+		t.Errorf("got %d results for no items, want 0", len(got))
+	}
+}
+
+func BenchmarkBloomFilterContainsBatchParallel(b *testing.B) {
+	const n = 1 << 20
+	bf := NewBloomFilter[int](n, 0.01)
+	for i := range n {
+		bf.Add(i)
+	}
+	items := make([]int, n)
+	for i := range items {
+		items[i] = i * 2
+	}
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers_%d", workers), func(b *testing.B) {
+			for b.Loop() {
+				bf.ContainsBatchParallel(items, workers)
+			}
+		})
+	}
+}
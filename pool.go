@@ -0,0 +1,91 @@
+package bloom
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrPoolExhausted is returned when creating a filter in a [Pool] would
+// exceed the pool's memory budget.
+var ErrPoolExhausted = errors.New("bloom: pool memory budget exceeded")
+
+// Pool limits the total memory used by a set of filters, such as one filter
+// per tenant in a service that creates filters dynamically. Filters are
+// created in a pool with [NewPooledFilter], and their memory is returned to it
+// with [Pool.Release].
+//
+// A Pool is safe for concurrent use.
+type Pool struct {
+	maxBytes uint
+
+	mu      sync.Mutex
+	used    uint
+	filters map[any]uint // filter -> bytes reserved for it
+}
+
+// NewPool creates a new Pool that allows filters using up to maxBytes of
+// memory in total, as measured by [Filter.MemoryUsage].
+func NewPool(maxBytes uint) *Pool {
+	return &Pool{
+		maxBytes: maxBytes,
+		filters:  make(map[any]uint),
+	}
+}
+
+// NewPooledFilter creates a new Bloom filter in the pool, like
+// [NewBloomFilter]. If the filter would take the pool's total memory usage
+// past its budget, no filter is allocated, and it returns [ErrPoolExhausted].
+func NewPooledFilter[T comparable](p *Pool, expectedItems uint, falsePositiveRate float64, opts ...Option) (*Filter[T], error) {
+	size := EstimatedMemory(expectedItems, falsePositiveRate)
+
+	p.mu.Lock()
+	if size > p.maxBytes-p.used {
+		p.mu.Unlock()
+		return nil, ErrPoolExhausted
+	}
+	p.used += size
+	p.mu.Unlock()
+
+	// Give the reservation back if NewBloomFilter panics.
+	created := false
+	defer func() {
+		if !created {
+			p.mu.Lock()
+			p.used -= size
+			p.mu.Unlock()
+		}
+	}()
+	bf := NewBloomFilter[T](expectedItems, falsePositiveRate, opts...)
+	created = true
+
+	p.mu.Lock()
+	p.filters[bf] = size
+	p.mu.Unlock()
+	return bf, nil
+}
+
+// Release returns the memory of a filter created by [NewPooledFilter] to the
+// pool. The filter must not be used afterwards. Releasing a filter that
+// isn't in the pool, or releasing it a second time, does nothing.
+func (p *Pool) Release(filter any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if size, ok := p.filters[filter]; ok {
+		delete(p.filters, filter)
+		p.used -= size
+	}
+}
+
+// Used returns the number of bytes used by the filters in the pool.
+func (p *Pool) Used() uint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.used
+}
+
+// Len returns the number of filters in the pool.
+func (p *Pool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.filters)
+}
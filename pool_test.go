@@ -0,0 +1,56 @@
+package bloom
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	size := NewBloomFilter[string](1000, 0.01).MemoryUsage()
+	p := NewPool(2*size + size/2)
+
+	a, err := NewPooledFilter[string](p, 1000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.MemoryUsage(); got != size {
+		t.Errorf("got memory usage %d, want %d", got, size)
+	}
+	if _, err := NewPooledFilter[int](p, 1000, 0.01); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.Used(), 2*size; got != want {
+		t.Errorf("got %d bytes used, want %d", got, want)
+	}
+
+	if _, err := NewPooledFilter[string](p, 1000, 0.01); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("got error %v, want %v", err, ErrPoolExhausted)
+	}
+	if got, want := p.Len(), 2; got != want {
+		t.Errorf("got %d filters, want %d", got, want)
+	}
+
+	p.Release(a)
+	p.Release(a)
+	if got, want := p.Used(), size; got != want {
+		t.Errorf("got %d bytes used after release, want %d", got, want)
+	}
+	if _, err := NewPooledFilter[string](p, 1000, 0.01); err != nil {
+		t.Errorf("got error %v after release, want nil", err)
+	}
+}
+
+func TestNewPooledFilter_Panic(t *testing.T) {
+	p := NewPool(1 << 20)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic for a type unsupported by WithFNVHash")
+			}
+		}()
+		NewPooledFilter[*int](p, 1000, 0.01, WithFNVHash())
+	}()
+	if got := p.Used(); got != 0 {
+		t.Errorf("got %d bytes used after a failed creation, want 0", got)
+	}
+}
@@ -0,0 +1,78 @@
+package bloom
+
+// prefilterBitsPerItem is the size of a PrefilteredFilter's summary, in bits
+// per expected item.
+const prefilterBitsPerItem = 2
+
+// PrefilteredFilter is a Bloom filter with a small first-level summary that
+// rejects most absent items with a single probe. The summary has two bits per
+// expected item, one of which is set by each item using a hash shared with the
+// underlying filter, so it is more likely to stay in the CPU's caches than the
+// underlying filter: a query whose summary bit is clear returns without
+// touching the underlying filter at all.
+//
+// This suits workloads where most queries are for absent items, against a
+// large filter holding well under its expected number of items; for a filter
+// at a sixteenth of its capacity, such queries are about twice as fast. At
+// capacity, about two in five absent items pass the summary, and the extra
+// probe makes every query that reaches the underlying filter slightly slower.
+// The summary adds about a fifth to the memory of a filter with a 1% false
+// positive rate, and doesn't change the false positive rate.
+type PrefilteredFilter[T comparable] struct {
+	filter  *Filter[T]
+	summary []uint64
+	m       uint // number of bits in summary
+}
+
+// NewPrefilteredFilter creates a new, empty PrefilteredFilter optimized for
+// the expected number of items and desired false positive rate.
+//
+// Any hooks set with [WithHooks] are only called for queries that reach the
+// underlying filter.
+//
+// It panics under the same conditions as [NewBloomFilter].
+func NewPrefilteredFilter[T comparable](expectedItems uint, falsePositiveRate float64, opts ...Option) *PrefilteredFilter[T] {
+	m := max(expectedItems, 1) * prefilterBitsPerItem
+	return &PrefilteredFilter[T]{
+		filter:  NewBloomFilter[T](expectedItems, falsePositiveRate, opts...),
+		summary: make([]uint64, (m+63)/64),
+		m:       m,
+	}
+}
+
+// Add inserts an item into the filter.
+//
+// This method is not safe for concurrent use.
+func (p *PrefilteredFilter[T]) Add(item T) {
+	pos := p.position(item)
+	p.summary[pos>>6] |= 1 << (pos & 63)
+	p.filter.Add(item)
+}
+
+// Contains tests whether an item might be in the set.
+// False positives are possible, but false negatives are not.
+//
+// This method can be called concurrently with other calls to itself, but not
+// [PrefilteredFilter.Add].
+func (p *PrefilteredFilter[T]) Contains(item T) bool {
+	pos := p.position(item)
+	if p.summary[pos>>6]&(1<<(pos&63)) == 0 {
+		return false
+	}
+	return p.filter.Contains(item)
+}
+
+// position returns the index of the summary bit that item maps to. It's
+// derived from the underlying filter's first hash function, remixed so that
+// the summary bit is independent of the filter's first bit.
+func (p *PrefilteredFilter[T]) position(item T) uint64 {
+	hash := p.filter.hashItem(item, p.filter.seeds[0])
+	return reduce(mix64(hash), p.m)
+}
+
+// Filter returns the underlying filter, which holds every item added to p.
+// Items added directly to it aren't recorded in the summary, so p doesn't
+// report them as present.
+func (p *PrefilteredFilter[T]) Filter() *Filter[T] {
+	return p.filter
+}
@@ -0,0 +1,62 @@
+package bloom
+
+import "testing"
+
+func TestPrefilteredFilter(t *testing.T) {
+	p := NewPrefilteredFilter[int](10000, 0.01, WithFNVHash())
+	for i := range 10000 {
+		p.Add(i)
+	}
+	for i := range 10000 {
+		if !p.Contains(i) {
+			t.Fatalf("%d should be in the filter", i)
+		}
+	}
+
+	// The summary shouldn't change the false positive rate.
+	var positives, filterPositives int
+	for i := 10000; i < 110000; i++ {
+		if p.Contains(i) {
+			positives++
+		}
+		if p.filter.Contains(i) {
+			filterPositives++
+		}
+	}
+	if positives > filterPositives {
+		t.Errorf("got %d false positives, more than the underlying filter's %d", positives, filterPositives)
+	}
+	if rate := float64(positives) / 100000; rate > 0.02 {
+		t.Errorf("got false positive rate %v, want at most 0.02", rate)
+	}
+}
+
+func BenchmarkPrefilteredFilterContains(b *testing.B) {
+	// A filter much larger than the CPU's caches, holding a small fraction
+	// of its capacity, queried only for absent items.
+	const (
+		capacity = 1 << 24
+		n        = capacity / 16
+	)
+	bf := NewBloomFilter[int](capacity, 0.01)
+	p := NewPrefilteredFilter[int](capacity, 0.01)
+	for i := range n {
+		bf.Add(i)
+		p.Add(i)
+	}
+
+	b.Run("filter", func(b *testing.B) {
+		i := 0
+		for b.Loop() {
+			bf.Contains(n + i*251%capacity)
+			i++
+		}
+	})
+	b.Run("prefiltered", func(b *testing.B) {
+		i := 0
+		for b.Loop() {
+			p.Contains(n + i*251%capacity)
+			i++
+		}
+	})
+}
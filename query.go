@@ -0,0 +1,49 @@
+package bloom
+
+// Query tests a single item against many filters, reusing the item's hashes
+// between filters that share hash functions. Filters share hash functions
+// when they use the same hashing scheme and seeds, such as filters created
+// with [WithFNVHash] and the same number of hash functions (or a prefix of
+// them), or filters created by [NewBloomFilterWithSeeds] from the same seeds;
+// the size of their bit arrays may differ.
+//
+// A Query can be tested against any filter, but for filters that don't share
+// hash functions with the one tested before, it rehashes the item.
+//
+// A Query is not safe for concurrent use.
+type Query[T comparable] struct {
+	item   T
+	fnv    bool     // hashing scheme of hashes
+	seeds  []uint64 // seeds of hashes
+	hashes []uint64 // hash of item for each of seeds
+}
+
+// NewQuery creates a new Query for item.
+func NewQuery[T comparable](item T) *Query[T] {
+	return &Query[T]{item: item}
+}
+
+// In tests whether the query's item might be in bf, like [Filter.Contains].
+func (q *Query[T]) In(bf *Filter[T]) bool {
+	if bf.fnv != q.fnv {
+		q.fnv = bf.fnv
+		q.seeds = q.seeds[:0]
+		q.hashes = q.hashes[:0]
+	}
+
+	found := true
+	for i, seed := range bf.seeds {
+		if i >= len(q.seeds) || q.seeds[i] != seed {
+			// Hashes from here on are for different seeds; replace them.
+			q.seeds = append(q.seeds[:i], seed)
+			q.hashes = append(q.hashes[:i], bf.hashItem(q.item, seed))
+		}
+		wordIndex, mask := bf.location(q.hashes[i])
+		if bf.bits[wordIndex]&mask == 0 {
+			found = false
+			break
+		}
+	}
+	bf.finishContains(found)
+	return found
+}
@@ -0,0 +1,57 @@
+package bloom
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	var filters []*Filter[string]
+	for i := range 10 {
+		// A mix of sizes and schemes, some sharing hash functions.
+		var bf *Filter[string]
+		switch i % 3 {
+		case 0:
+			bf = NewBloomFilter[string](uint(100*(i+1)), 0.01, WithFNVHash())
+		case 1:
+			bf = NewBloomFilter[string](uint(100*(i+1)), 0.01)
+		case 2:
+			bf, _ = NewBloomFilterWithSeeds[string](uint(1000*(i+1)), uint(len(filters[1].seeds)), filters[1].Seeds())
+		}
+		for j := range 100 {
+			if j%(i+1) == 0 {
+				bf.Add(fmt.Sprint(j))
+			}
+		}
+		filters = append(filters, bf)
+	}
+
+	for j := range 200 {
+		item := fmt.Sprint(j)
+		q := NewQuery(item)
+		for i, bf := range filters {
+			if got, want := q.In(bf), bf.Contains(item); got != want {
+				t.Errorf("filter %d: In(%q) = %v, want %v", i, item, got, want)
+			}
+		}
+	}
+}
+
+func TestQuery_ReusesHashes(t *testing.T) {
+	a := NewBloomFilter[string](1000, 0.01, WithFNVHash())
+	b := NewBloomFilter[string](5000, 0.01, WithFNVHash())
+	a.Add("apple")
+	b.Add("apple")
+
+	q := NewQuery("apple")
+	if !q.In(a) {
+		t.Fatal("'apple' should be in a")
+	}
+
+	// Changing the item behind the query's back shows whether b is tested
+	// with the hashes cached from a.
+	q.item = "banana"
+	if !q.In(b) {
+		t.Error("hashes should be reused for filters sharing hash functions")
+	}
+}
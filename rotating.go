@@ -0,0 +1,105 @@
+package bloom
+
+// RotatingFilter is a Bloom filter over a sliding window of items, made up
+// of several generations of equal size. Items are added to the newest
+// generation; when it is full, the filter rotates, clearing the oldest
+// generation and making it the newest. An item is therefore forgotten after
+// at least generations-1 further generations have been filled.
+type RotatingFilter[T comparable] struct {
+	gens    []*Filter[T] // ring of generations
+	current int          // index in gens of the newest generation
+}
+
+// NewRotatingFilter creates a new, empty RotatingFilter with the given number
+// of generations, each sized for perGeneration items at the desired false
+// positive rate. Since a query checks every generation, the filter's overall
+// false positive rate is up to generations times falsePositiveRate.
+//
+// Generations rotate when their entry count reaches perGeneration, so
+// NewRotatingFilter panics if opts include [WithoutEntryCounting]. It also
+// panics if generations is less than one, and otherwise under the same
+// conditions as [NewBloomFilter].
+func NewRotatingFilter[T comparable](generations int, perGeneration uint, falsePositiveRate float64, opts ...Option) *RotatingFilter[T] {
+	if generations < 1 {
+		panic("bloom: at least one generation is required")
+	}
+	gens := make([]*Filter[T], generations)
+	gens[0] = NewBloomFilter[T](perGeneration, falsePositiveRate, opts...)
+	if gens[0].noEntries {
+		panic("bloom: a rotating filter requires entry counting")
+	}
+	for i := 1; i < generations; i++ {
+		gens[i] = gens[0].emptyCopy()
+	}
+	return &RotatingFilter[T]{gens: gens}
+}
+
+// generation returns generation i, where 0 is the newest.
+func (rf *RotatingFilter[T]) generation(i int) *Filter[T] {
+	return rf.gens[(rf.current-i+len(rf.gens))%len(rf.gens)]
+}
+
+// Add inserts an item into the newest generation, rotating first if it is
+// full.
+//
+// This method is not safe for concurrent use.
+func (rf *RotatingFilter[T]) Add(item T) {
+	if newest := rf.generation(0); newest.entries >= newest.expectedItems {
+		rf.Rotate()
+	}
+	rf.generation(0).Add(item)
+}
+
+// Rotate clears the oldest generation and makes it the newest, forgetting
+// the items that were added to it.
+//
+// This method is not safe for concurrent use.
+func (rf *RotatingFilter[T]) Rotate() {
+	rf.current = (rf.current + 1) % len(rf.gens)
+	rf.gens[rf.current].Reset()
+}
+
+// ClearGeneration clears generation i, where generation 0 is the newest and
+// generation [RotatingFilter.Generations]-1 the oldest, forgetting the items
+// that were added to it without rotating. Items in other generations are
+// still found, and the items in generation i no longer count towards
+// [RotatingFilter.Entries]. It panics if i is out of range.
+//
+// This method is not safe for concurrent use.
+func (rf *RotatingFilter[T]) ClearGeneration(i int) {
+	if i < 0 || i >= len(rf.gens) {
+		panic("bloom: generation out of range")
+	}
+	rf.generation(i).Reset()
+}
+
+// Contains tests whether an item might be in any generation.
+// False positives are possible, but false negatives are not, for items that
+// haven't been forgotten.
+//
+// This method can be called concurrently with other calls to itself, but not
+// [RotatingFilter.Add], [RotatingFilter.Rotate] or
+// [RotatingFilter.ClearGeneration].
+func (rf *RotatingFilter[T]) Contains(item T) bool {
+	for _, gen := range rf.gens {
+		if gen.Contains(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// Generations returns the number of generations in the filter.
+func (rf *RotatingFilter[T]) Generations() int {
+	return len(rf.gens)
+}
+
+// Entries returns the number of items added to the generations that haven't
+// been cleared.
+func (rf *RotatingFilter[T]) Entries() uint {
+	var n uint
+	for _, gen := range rf.gens {
+		n += gen.entries
+	}
+	return n
+}
@@ -0,0 +1,67 @@
+package bloom
+
+import (
+	"testing"
+)
+
+func TestRotatingFilter(t *testing.T) {
+	rf := NewRotatingFilter[int](3, 100, 0.0001, WithFNVHash())
+	for i := range 300 {
+		rf.Add(i)
+	}
+	for i := range 300 {
+		if !rf.Contains(i) {
+			t.Fatalf("%d should be in the filter", i)
+		}
+	}
+
+	// Filling a fourth generation forgets the first.
+	for i := 300; i < 400; i++ {
+		rf.Add(i)
+	}
+	if rf.Contains(0) || rf.Contains(99) {
+		t.Error("items in the oldest generation should be forgotten")
+	}
+	if !rf.Contains(100) || !rf.Contains(399) {
+		t.Error("items in newer generations should still be found")
+	}
+	if got, want := rf.Entries(), uint(300); got != want {
+		t.Errorf("got %d entries, want %d", got, want)
+	}
+}
+
+func TestRotatingFilter_ClearGeneration(t *testing.T) {
+	rf := NewRotatingFilter[int](3, 100, 0.0001, WithFNVHash())
+	for i := range 300 {
+		rf.Add(i) // generation 2 holds 0-99, and generation 0 holds 200-299
+	}
+
+	rf.ClearGeneration(rf.Generations() - 1)
+	if rf.Contains(0) || rf.Contains(99) {
+		t.Error("items in the cleared generation should be forgotten")
+	}
+	for i := 100; i < 300; i++ {
+		if !rf.Contains(i) {
+			t.Fatalf("%d should still be in the filter", i)
+		}
+	}
+	if got, want := rf.Entries(), uint(200); got != want {
+		t.Errorf("got %d entries, want %d", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for out of range generation")
+		}
+	}()
+	rf.ClearGeneration(3)
+}
+
+func TestNewRotatingFilter_WithoutEntryCounting(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for a rotating filter without entry counting")
+		}
+	}()
+	NewRotatingFilter[int](3, 100, 0.01, WithoutEntryCounting())
+}
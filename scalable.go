@@ -0,0 +1,159 @@
+package bloom
+
+import "math"
+
+// ScalableFilter is a Bloom filter that grows without bound by adding stages,
+// following Almeida et al., "Scalable Bloom Filters" (2007). Items are added
+// to the newest stage; once it is full, a larger stage with a tighter false
+// positive rate is added, so that the compound false positive rate across all
+// stages stays below the target.
+//
+// Unlike [GrowableFilter], a ScalableFilter never needs to re-enumerate its
+// items, but each query checks every stage.
+type ScalableFilter[T comparable] struct {
+	stages     []*Filter[T]
+	next       *Filter[T] // preallocated next stage, if any
+	fpr        float64
+	growth     float64
+	tightening float64
+}
+
+// A ScalableOption configures a [ScalableFilter] at construction time.
+type ScalableOption func(*scalableOptions)
+
+// scalableOptions holds the tunables set by a list of [ScalableOption]
+// values.
+type scalableOptions struct {
+	growth     float64
+	tightening float64
+}
+
+// WithGrowthRatio sets how many times larger the capacity of each new stage
+// of a [ScalableFilter] is than the last. The default is 2.
+//
+// A larger ratio adds stages less often, so queries check fewer stages and
+// the false positive rate is spent on fewer stages, at the cost of allocating
+// memory in larger steps: with ratio r, up to a fraction (r-1)/r of the
+// filter's memory may be allocated to a stage that is mostly empty. The ratio
+// must be at least 1.
+func WithGrowthRatio(r float64) ScalableOption {
+	return func(o *scalableOptions) {
+		o.growth = r
+	}
+}
+
+// WithTighteningRatio sets the factor by which the false positive rate of
+// each new stage of a [ScalableFilter] is reduced from the last. The default
+// is 0.8.
+//
+// The first stage's rate is the target times (1-s), for a tightening ratio
+// s, so the sum of the stages' rates converges to the target. A ratio close
+// to 1 gives the early stages more of the false positive budget, making them
+// smaller, but later stages need more bits per item, increasing the memory
+// of a filter that grows large; a ratio close to 0 does the opposite. The
+// ratio must be greater than 0 and less than 1.
+func WithTighteningRatio(s float64) ScalableOption {
+	return func(o *scalableOptions) {
+		o.tightening = s
+	}
+}
+
+// NewScalableFilter creates a new, empty ScalableFilter whose first stage is
+// sized for initial items, and whose compound false positive rate stays below
+// falsePositiveRate however many items are added.
+//
+// It panics if the growth or tightening ratio is invalid, and otherwise under
+// the same conditions as [NewBloomFilter].
+func NewScalableFilter[T comparable](initial uint, falsePositiveRate float64, opts ...ScalableOption) *ScalableFilter[T] {
+	o := scalableOptions{growth: 2, tightening: 0.8}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if !(o.growth >= 1) || math.IsInf(o.growth, 0) {
+		panic("bloom: growth ratio must be at least 1")
+	}
+	if !(o.tightening > 0 && o.tightening < 1) {
+		panic("bloom: tightening ratio must be between 0 and 1")
+	}
+
+	sf := &ScalableFilter[T]{
+		fpr:        checkFalsePositiveRate(falsePositiveRate),
+		growth:     o.growth,
+		tightening: o.tightening,
+	}
+	initial = max(initial, 1)
+	sf.stages = []*Filter[T]{NewBloomFilter[T](initial, sf.fpr*(1-sf.tightening))}
+	return sf
+}
+
+// Add inserts an item into the filter, adding a new stage first if the
+// current one is at capacity.
+//
+// This method is not safe for concurrent use.
+func (sf *ScalableFilter[T]) Add(item T) {
+	last := len(sf.stages) - 1
+	if sf.stages[last].entries >= sf.stages[last].expectedItems {
+		next := sf.next
+		if next == nil {
+			next = sf.newNextStage()
+		}
+		sf.stages = append(sf.stages, next)
+		sf.next = nil
+		last++
+	}
+	sf.stages[last].Add(item)
+}
+
+// newNextStage returns a new, empty stage to follow the current last one.
+func (sf *ScalableFilter[T]) newNextStage() *Filter[T] {
+	last := len(sf.stages) - 1
+	capacity := uint(math.Ceil(float64(sf.stages[last].expectedItems) * sf.growth))
+	return NewBloomFilter[T](capacity, sf.stages[last].targetFPR*sf.tightening)
+}
+
+// PrewarmNextStage allocates the stage that will be added once the current
+// one is full, if it hasn't been already, so that the [ScalableFilter.Add]
+// that fills the current stage doesn't have to. Calling it from a
+// background goroutine, or at a quiet time, moves the latency and garbage
+// collection work of allocating a large bit array off the ingestion path.
+//
+// The cost is that the next stage's memory, which with the default growth
+// ratio is as much as all of the existing stages put together, is held
+// before it's needed.
+//
+// This method is not safe for concurrent use.
+func (sf *ScalableFilter[T]) PrewarmNextStage() {
+	if sf.next == nil {
+		sf.next = sf.newNextStage()
+	}
+}
+
+// Contains tests whether an item might be in the set.
+// False positives are possible, but false negatives are not.
+//
+// This method can be called concurrently with other calls to itself, but not
+// [ScalableFilter.Add].
+func (sf *ScalableFilter[T]) Contains(item T) bool {
+	for _, stage := range sf.stages {
+		if stage.Contains(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stages returns the number of stages in the filter.
+func (sf *ScalableFilter[T]) Stages() int {
+	return len(sf.stages)
+}
+
+// EstimatedFalsePositiveRate returns the estimated compound false positive
+// rate across all stages: the probability that any stage reports a false
+// positive.
+func (sf *ScalableFilter[T]) EstimatedFalsePositiveRate() float64 {
+	negative := 1.0
+	for _, stage := range sf.stages {
+		negative *= 1 - stage.EstimatedFalsePositiveRate()
+	}
+	return 1 - negative
+}
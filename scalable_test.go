@@ -0,0 +1,89 @@
+package bloom
+
+import (
+	"testing"
+)
+
+func TestScalableFilter(t *testing.T) {
+	sf := NewScalableFilter[int](100, 0.01)
+	for i := range 1000 {
+		sf.Add(i)
+	}
+
+	if got, want := sf.Stages(), 4; got != want { // 100 + 200 + 400 + 800
+		t.Errorf("got %d stages, want %d", got, want)
+	}
+	for i := range 1000 {
+		if !sf.Contains(i) {
+			t.Fatalf("%d should be in the filter", i)
+		}
+	}
+	if fpr := sf.EstimatedFalsePositiveRate(); fpr > 0.01 {
+		t.Errorf("got estimated FPR %v, want <= 0.01", fpr)
+	}
+}
+
+func TestScalableFilter_Options(t *testing.T) {
+	sf := NewScalableFilter[int](100, 0.01, WithGrowthRatio(4), WithTighteningRatio(0.5))
+	for i := range 2000 {
+		sf.Add(i)
+	}
+
+	if got, want := sf.Stages(), 3; got != want { // 100 + 400 + 1600
+		t.Errorf("got %d stages, want %d", got, want)
+	}
+	if got, want := sf.stages[0].targetFPR, 0.005; got != want {
+		t.Errorf("got first stage FPR %v, want %v", got, want)
+	}
+	if got, want := sf.stages[2].targetFPR, 0.00125; got != want {
+		t.Errorf("got third stage FPR %v, want %v", got, want)
+	}
+	if fpr := sf.EstimatedFalsePositiveRate(); fpr > 0.01 {
+		t.Errorf("got estimated FPR %v, want <= 0.01", fpr)
+	}
+}
+
+func TestScalableFilter_InvalidOptions(t *testing.T) {
+	for _, opt := range []ScalableOption{
+		WithGrowthRatio(0.5),
+		WithTighteningRatio(0),
+		WithTighteningRatio(1),
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic for invalid option")
+				}
+			}()
+			NewScalableFilter[int](100, 0.01, opt)
+		}()
+	}
+}
+
+func TestScalableFilter_PrewarmNextStage(t *testing.T) {
+	sf := NewScalableFilter[int](100, 0.01)
+	sf.PrewarmNextStage()
+	next := sf.next
+	sf.PrewarmNextStage()
+	if sf.next != next {
+		t.Error("prewarming twice should reuse the preallocated stage")
+	}
+
+	for i := range 101 {
+		sf.Add(i)
+	}
+	if got, want := sf.Stages(), 2; got != want {
+		t.Fatalf("got %d stages, want %d", got, want)
+	}
+	if sf.stages[1] != next {
+		t.Error("the preallocated stage should become the next stage")
+	}
+	if sf.next != nil {
+		t.Error("the preallocated stage should be used up")
+	}
+	for i := range 101 {
+		if !sf.Contains(i) {
+			t.Fatalf("%d should be in the filter", i)
+		}
+	}
+}
@@ -0,0 +1,472 @@
+package bloom
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+const (
+	// healthMaxFillRatio is the fill ratio above which Health reports a
+	// filter as degraded. A filter using the optimal number of hash
+	// functions is half full at its expected capacity.
+	healthMaxFillRatio = 0.7
+
+	// healthMaxFPRMultiple is how many times its target false positive
+	// rate a filter's actual rate may reach before Health reports it as
+	// degraded.
+	healthMaxFPRMultiple = 2
+)
+
+// saturationCheck counts calls for [WithSaturationCheck].
+type saturationCheck struct {
+	interval uint64
+	calls    atomic.Uint64
+}
+
+// checkSaturation counts n calls to Add or Contains, and checks whether the
+// filter is saturated once every interval calls.
+func (bf *Filter[T]) checkSaturation(n uint64) {
+	calls := bf.saturation.calls.Add(n)
+	if calls/bf.saturation.interval == (calls-n)/bf.saturation.interval {
+		return
+	}
+	if fill := bf.FillRatio(); fill > healthMaxFillRatio && bf.hooks.OnSaturated != nil {
+		bf.hooks.OnSaturated(fill)
+	}
+}
+
+// intervalCounters counts calls for [WithIntervalStats].
+type intervalCounters struct {
+	adds      atomic.Uint64
+	contains  atomic.Uint64
+	positives atomic.Uint64
+}
+
+// addCounters adds the calls counted by other's saturation check and interval
+// counters to bf's, where both filters have them.
+func (bf *Filter[T]) addCounters(other *Filter[T]) {
+	if bf.saturation != nil && other.saturation != nil {
+		bf.saturation.calls.Add(other.saturation.calls.Load())
+	}
+	if bf.interval != nil && other.interval != nil {
+		bf.interval.adds.Add(other.interval.adds.Load())
+		bf.interval.contains.Add(other.interval.contains.Load())
+		bf.interval.positives.Add(other.interval.positives.Load())
+	}
+}
+
+// IntervalStats counts the calls made to a filter during an interval; see
+// [Filter.SnapshotStats].
+type IntervalStats struct {
+	Adds      uint64 // calls to Add
+	Contains  uint64 // calls to Contains
+	Positives uint64 // calls to Contains that returned true
+}
+
+// SnapshotStats returns the number of calls made to the filter since the last
+// call to SnapshotStats, or since it was created, and resets the counts to
+// zero. It returns zero counts unless the filter was created with
+// [WithIntervalStats].
+//
+// Each count is read and reset atomically, so no call is missed or counted
+// twice, but a call made while SnapshotStats runs may be counted in Contains
+// in one interval and in Positives in the next.
+//
+// This method can be called concurrently with any other method, including
+// [Filter.Add].
+func (bf *Filter[T]) SnapshotStats() IntervalStats {
+	if bf.interval == nil {
+		return IntervalStats{}
+	}
+	return IntervalStats{
+		Adds:      bf.interval.adds.Swap(0),
+		Contains:  bf.interval.contains.Swap(0),
+		Positives: bf.interval.positives.Swap(0),
+	}
+}
+
+// FillRatio returns the fraction of the filter's bits that are set.
+//
+// This method can be called concurrently with other calls to [Filter.Contains]
+// or itself.
+func (bf *Filter[T]) FillRatio() float64 {
+	return float64(bf.validBitCount()) / float64(bf.m)
+}
+
+// MemoryUsage returns the approximate number of bytes of memory used by the
+// filter's bit array and hash seeds.
+func (bf *Filter[T]) MemoryUsage() uint {
+	return filterMemoryUsage(bf.m, uint(len(bf.seeds)))
+}
+
+// EstimatedMemory returns the number of bytes, as reported by
+// [Filter.MemoryUsage], that a filter created by [NewBloomFilter] with the
+// same arguments would use, without allocating it.
+//
+// It panics if falsePositiveRate is NaN or not greater than zero.
+func EstimatedMemory(expectedItems uint, falsePositiveRate float64) uint {
+	return filterMemoryUsage(bloomParams(expectedItems, falsePositiveRate))
+}
+
+// filterMemoryUsage returns the memory used by a filter with an m-bit array
+// and k hash functions, as reported by [Filter.MemoryUsage].
+func filterMemoryUsage(m, k uint) uint {
+	return (m+63)/64*8 + k*8 + uint(unsafe.Sizeof(Filter[struct{}]{}))
+}
+
+// TuneK changes the filter's number of hash functions to the optimal number
+// for the size of its bit array and the number of items currently in it,
+// (m/n)·ln 2, minimizing its false positive rate from now on. It returns the
+// new number of hash functions. An empty filter is left unchanged.
+//
+// Reducing the number of hash functions drops the last of them, so every
+// item already added is still found. Increasing it adds hash functions whose
+// bits aren't set for the items already added, so in that case TuneK resets
+// the filter and reports rebuild as true: the caller must add every item
+// again, or the filter will return false negatives. The new hash functions
+// are separated by the filter's [WithDomain] domain, like the old ones, except
+// for filters that were decoded, which don't record their domain.
+//
+// Changing the number of hash functions changes the filter's
+// [Filter.ConfigHash], so it can no longer be combined with filters that
+// shared its old configuration.
+//
+// This method is not safe for concurrent use.
+func (bf *Filter[T]) TuneK() (k uint, rebuild bool) {
+	n := float64(bf.entries)
+	if bf.noEntries {
+		n = bf.EstimatedCardinality()
+	}
+	if !(n >= 1) {
+		return uint(len(bf.seeds)), false
+	}
+
+	k = max(uint(math.Round(float64(bf.m)/n*math.Ln2)), 1)
+	switch old := uint(len(bf.seeds)); {
+	case k < old:
+		bf.seeds = bf.seeds[:k:k]
+	case k > old:
+		var more []uint64
+		if bf.fnv {
+			more = fnvSeeds(k)[old:]
+		} else {
+			more = randomSeeds(k - old)
+		}
+		options{domain: bf.domain}.applyDomain(more)
+		bf.seeds = append(bf.seeds, more...)
+		bf.Reset()
+		rebuild = true
+	}
+	return k, rebuild
+}
+
+// PeakFillRatio returns the highest [Filter.FillRatio] the filter has had,
+// including before it was last [Filter.Reset]. Since bits are only ever set
+// between resets, the fill ratio only needs to be measured when the filter
+// is reset, so this costs nothing on [Filter.Add].
+//
+// This method can be called concurrently with other calls to [Filter.Contains]
+// or itself.
+func (bf *Filter[T]) PeakFillRatio() float64 {
+	return max(bf.peakFill, bf.FillRatio())
+}
+
+// EstimatedCardinality estimates the number of distinct items in the filter
+// from the number of set bits X, as -(m/k)·ln(1 - X/m) (Swamidass and Baldi,
+// 2007). Unlike the entry count, it isn't inflated by adding the same item
+// more than once. It is +Inf if every bit is set.
+//
+// This method can be called concurrently with other calls to [Filter.Contains]
+// or itself.
+func (bf *Filter[T]) EstimatedCardinality() float64 {
+	m, k := float64(bf.m), float64(len(bf.seeds))
+	return -m / k * math.Log1p(-float64(bf.validBitCount())/m)
+}
+
+// EstimatedDuplicateRate estimates the fraction of the items added to the
+// filter that were already present, by comparing the number of items added
+// with [Filter.EstimatedCardinality]. It returns zero for an empty filter, or
+// one without an entry count.
+//
+// Since the estimated cardinality has some variance, small duplicate rates
+// can't be distinguished from zero.
+//
+// This method can be called concurrently with other calls to [Filter.Contains]
+// or itself.
+func (bf *Filter[T]) EstimatedDuplicateRate() float64 {
+	if bf.noEntries || bf.entries == 0 {
+		return 0
+	}
+	return min(max(1-bf.EstimatedCardinality()/float64(bf.entries), 0), 1)
+}
+
+// OptimalCapacity returns the number of items for which the filter's number of
+// hash functions is optimal given the size of its bit array, (m/k)·ln 2. A
+// filter created by [NewBloomFilter] has an OptimalCapacity close to the
+// number of items it was sized for; a large difference suggests that the
+// filter's size and number of hash functions are mismatched.
+func (bf *Filter[T]) OptimalCapacity() uint {
+	return uint(float64(bf.m) / float64(len(bf.seeds)) * math.Ln2)
+}
+
+// MinBitsPerElement returns the number of bits per item that a Bloom filter
+// needs to reach the false positive rate fpr, -ln(fpr)/ln(2)², assuming the
+// optimal number of hash functions. This is the size [NewBloomFilter] aims
+// for; it is about 1.44 times the information-theoretic lower bound of
+// log2(1/fpr) bits per item for any approximate membership structure.
+func MinBitsPerElement(fpr float64) float64 {
+	return -math.Log(fpr) / (math.Ln2 * math.Ln2)
+}
+
+// BitsPerElement returns the number of bits in the filter's bit array per item
+// added, the space efficiency the filter actually achieves at its current
+// load, for comparison with [MinBitsPerElement]. It returns +Inf if no items
+// have been counted, including for filters created with
+// [WithoutEntryCounting].
+func (bf *Filter[T]) BitsPerElement() float64 {
+	if bf.entries == 0 {
+		return math.Inf(1)
+	}
+	return float64(bf.m) / float64(bf.entries)
+}
+
+// RemainingCapacity returns how many more items can be added to the filter
+// before its estimated false positive rate exceeds the target it was sized
+// for, or zero if it already has. The capacity at the target is found by
+// inverting the formula used by [FalsePositiveRate].
+//
+// With entry counting disabled, the number of items already added is
+// estimated from the number of set bits. For filters created by
+// [NewBloomFilterWithSeeds], which have no target, it returns zero, and for
+// filters whose target can't be exceeded, such as a target of one, it returns
+// [math.MaxUint].
+func (bf *Filter[T]) RemainingCapacity() uint {
+	if bf.targetFPR <= 0 {
+		return 0
+	}
+	m, k := float64(bf.m), float64(len(bf.seeds))
+	capacity := -m / k * math.Log1p(-math.Pow(bf.targetFPR, 1/k))
+
+	entries := float64(bf.entries)
+	if bf.noEntries {
+		entries = bf.EstimatedCardinality()
+	}
+	if !(capacity > entries) {
+		return 0
+	}
+	// Converting a float64 that a uint can't hold is implementation-defined.
+	if remaining := capacity - entries; remaining < math.MaxUint {
+		return uint(remaining)
+	}
+	return math.MaxUint
+}
+
+// BitsSavedBy returns how many fewer bits the filter would need if it were
+// sized for the same number of items at newFPR, rather than its current size.
+// The result is negative if newFPR would need more bits than the filter has,
+// such as when newFPR is tighter than the filter's target.
+//
+// For filters created by [NewBloomFilterWithSeeds], whose expected number of
+// items isn't known, the filter's [Filter.OptimalCapacity] is used instead.
+//
+// It panics if newFPR is NaN or not greater than zero.
+func (bf *Filter[T]) BitsSavedBy(newFPR float64) int {
+	n := bf.expectedItems
+	if n == 0 {
+		n = bf.OptimalCapacity()
+	}
+	m, _ := bloomParams(n, newFPR)
+	return int(bf.m) - int(m)
+}
+
+// Health reports whether the filter is still operating within its design
+// parameters. If not, reason describes each problem found:
+//
+//   - more items have been added than the filter was sized for,
+//   - more than 70% of its bits are set, or
+//   - its [Filter.ActualFalsePositiveRate] is more than twice the target
+//     false positive rate it was created with.
+//
+// Checks that depend on the parameters a filter was sized for are skipped for
+// filters created by [NewBloomFilterWithSeeds].
+//
+// This method can be called concurrently with other calls to [Filter.Contains]
+// or itself.
+func (bf *Filter[T]) Health() (ok bool, reason string) {
+	var problems []string
+	if bf.expectedItems > 0 && !bf.noEntries && bf.entries > bf.expectedItems {
+		problems = append(problems, fmt.Sprintf("contains %d items, more than the %d it was sized for", bf.entries, bf.expectedItems))
+	}
+	if fill := bf.FillRatio(); fill > healthMaxFillRatio {
+		problems = append(problems, fmt.Sprintf("fill ratio %.3f exceeds %.3f", fill, healthMaxFillRatio))
+	}
+	if bf.targetFPR > 0 {
+		if fpr := bf.ActualFalsePositiveRate(); fpr > healthMaxFPRMultiple*bf.targetFPR {
+			problems = append(problems, fmt.Sprintf("false positive rate %.3g exceeds %d times the target of %.3g", fpr, healthMaxFPRMultiple, bf.targetFPR))
+		}
+	}
+	if len(problems) > 0 {
+		return false, strings.Join(problems, "; ")
+	}
+	return true, ""
+}
+
+// DensityMap divides the filter's bit array into a grid of width×height
+// cells, in row-major order, and returns the fraction of each cell's bits that
+// are set, scaled to 0-255, for rendering as an image. With well-distributed
+// hashes, the densities are uniform; visible structure indicates clustering.
+// If the grid has more cells than the filter has bits, some cells are empty,
+// with a density of zero.
+//
+// It panics if width or height is less than one.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) DensityMap(width, height int) [][]uint8 {
+	if width < 1 || height < 1 {
+		panic("bloom: density map dimensions must be positive")
+	}
+
+	cells := uint(width) * uint(height)
+	grid := make([][]uint8, height)
+	for row := range grid {
+		grid[row] = make([]uint8, width)
+		for col := range grid[row] {
+			cell := uint(row*width + col)
+			lo, hi := bf.cellBounds(cell, cells)
+			if hi > lo {
+				density := float64(bf.countRange(lo, hi)) / float64(hi-lo)
+				grid[row][col] = uint8(math.Round(density * 255))
+			}
+		}
+	}
+	return grid
+}
+
+// cellBounds returns the range of bits [lo, hi) in cell i of n equal cells.
+func (bf *Filter[T]) cellBounds(i, n uint) (lo, hi uint) {
+	return scaleBits(i, bf.m, n), scaleBits(i+1, bf.m, n)
+}
+
+// scaleBits returns i·m/n, without overflow, for i <= n.
+func scaleBits(i, m, n uint) uint {
+	hi, lo := bits.Mul64(uint64(i), uint64(m))
+	q, _ := bits.Div64(hi, lo, uint64(n))
+	return uint(q)
+}
+
+// countRange returns the number of set bits in [lo, hi).
+func (bf *Filter[T]) countRange(lo, hi uint) uint {
+	var n int
+	for lo < hi {
+		word := bf.bits[lo/64] >> (lo % 64)
+		width := min(64-lo%64, hi-lo)
+		if width < 64 {
+			word &= 1<<width - 1
+		}
+		n += bits.OnesCount64(word)
+		lo += width
+	}
+	return uint(n)
+}
+
+// SetBitRunHistogram returns the distribution of the lengths of runs of
+// consecutive set bits in the filter, as a map from run length to the number
+// of runs of that length. With well-distributed hashes, run lengths follow a
+// geometric distribution; unusually long runs indicate correlated hashes.
+//
+// This is a diagnostic tool and takes time proportional to the size of the
+// filter.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) SetBitRunHistogram() map[int]int {
+	hist := make(map[int]int)
+	run := 0
+	for i := range bf.m {
+		if bf.bits[i/64]&(1<<(i%64)) != 0 {
+			run++
+			continue
+		}
+		if run > 0 {
+			hist[run]++
+			run = 0
+		}
+	}
+	if run > 0 {
+		hist[run]++
+	}
+	return hist
+}
+
+// UniformityScore returns a measure, between zero and one, of how uniformly
+// the filter's set bits are spread across its bit array, for monitoring hash
+// quality. It compares the variance of the number of set bits in each 64-bit
+// word with the variance expected if every bit were set independently at the
+// filter's fill ratio: with well-distributed hashes the two are close and the
+// score is near one, while clustered bits, such as from a bad seed or
+// correlated hashes, give a lower score. Filters with fewer than two words, or
+// whose bits are all set or all clear, score one.
+//
+// This is a diagnostic tool and takes time proportional to the size of the
+// filter.
+//
+// This method can be called concurrently with other calls to [Filter.Contains],
+// but not [Filter.Add].
+func (bf *Filter[T]) UniformityScore() float64 {
+	// Only whole words are compared, so that the final word, which may
+	// hold fewer than 64 valid bits, doesn't skew the variance.
+	words := bf.bits[:bf.m/64]
+	if len(words) < 2 {
+		return 1
+	}
+
+	var total int
+	for _, word := range words {
+		total += bits.OnesCount64(word)
+	}
+	n := float64(len(words))
+	mean := float64(total) / n
+	expected := mean * (1 - mean/64) // binomial variance with p = mean/64
+	if expected == 0 {
+		return 1
+	}
+
+	var sumSquares float64
+	for _, word := range words {
+		d := float64(bits.OnesCount64(word)) - mean
+		sumSquares += d * d
+	}
+	observed := sumSquares / (n - 1)
+	if observed <= expected {
+		return 1
+	}
+	return expected / observed
+}
+
+// RecommendShards returns the smallest number of shards to split totalItems
+// items into, such as for filters in a [Directory], so that a filter for each
+// shard's items at the desired false positive rate uses at most
+// maxBytesPerShard bytes, as reported by [Filter.MemoryUsage]. It returns zero
+// if maxBytesPerShard is too small for even a filter of one item.
+//
+// It panics if falsePositiveRate is NaN or not greater than zero.
+func RecommendShards(totalItems uint, falsePositiveRate float64, maxBytesPerShard uint) int {
+	fits := func(shards uint) bool {
+		return EstimatedMemory((totalItems+shards-1)/shards, falsePositiveRate) <= maxBytesPerShard
+	}
+
+	totalItems = max(totalItems, 1)
+	if !fits(totalItems) {
+		return 0
+	}
+
+	// Memory per shard doesn't increase with the number of shards, so
+	// search for the smallest number that fits.
+	return int(sort.Search(int(totalItems), func(i int) bool { return fits(uint(i) + 1) })) + 1
+}
@@ -0,0 +1,525 @@
+package bloom
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBloomFilter_SetBitRunHistogram(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	if hist := bf.SetBitRunHistogram(); len(hist) != 0 {
+		t.Errorf("got %v for empty filter, want empty histogram", hist)
+	}
+
+	// Set bits directly to get a known pattern: runs of 1, 3 and 2, with
+	// the last run ending at the final bit.
+	setBit := func(i uint) { bf.bits[i/64] |= 1 << (i % 64) }
+	bf.bits[0] = 0b0111_0001
+	setBit(bf.m - 1)
+	setBit(bf.m - 2)
+
+	want := map[int]int{1: 1, 3: 1, 2: 1}
+	if got := bf.SetBitRunHistogram(); !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestBloomFilter_UniformityScore(t *testing.T) {
+	bf := NewBloomFilter[int](10000, 0.01, WithFNVHash())
+	if got := bf.UniformityScore(); got != 1 {
+		t.Errorf("got %v for empty filter, want 1", got)
+	}
+
+	for i := range 10000 {
+		bf.Add(i)
+	}
+	if got := bf.UniformityScore(); got < 0.9 {
+		t.Errorf("got %v for well-distributed hashes, want at least 0.9", got)
+	}
+
+	// Half the words full and half empty has the same fill ratio, but is
+	// as clustered as possible.
+	for i := range bf.bits {
+		bf.bits[i] = 0
+		if i%2 == 0 {
+			bf.bits[i] = math.MaxUint64
+		}
+	}
+	if got := bf.UniformityScore(); got > 0.1 {
+		t.Errorf("got %v for clustered bits, want at most 0.1", got)
+	}
+}
+
+func TestBloomFilter_TailBits(t *testing.T) {
+	bf, err := NewBloomFilterWithSeeds[int](100, 3, []uint64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Set every bit in the first 100 and beyond, as corrupt input could.
+	for i := range bf.bits {
+		bf.bits[i] = ^uint64(0)
+	}
+	if got, want := bf.validBitCount(), uint(100); got != want {
+		t.Errorf("got %d set bits, want %d", got, want)
+	}
+	if got := bf.FillRatio(); got != 1 {
+		t.Errorf("got fill ratio %v, want 1", got)
+	}
+	if got, want := bf.Bits()[1], uint64(1<<36-1); got != want {
+		t.Errorf("got last word %#x from Bits, want %#x", got, want)
+	}
+	if got, want := bf.SetBitRunHistogram(), map[int]int{100: 1}; !maps.Equal(got, want) {
+		t.Errorf("got histogram %v, want %v", got, want)
+	}
+
+	// With a multiple of 64 bits, the last word is entirely valid.
+	bf, err = NewBloomFilterWithSeeds[int](128, 3, []uint64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bf.bits[1] = ^uint64(0)
+	if got, want := bf.validBitCount(), uint(64); got != want {
+		t.Errorf("got %d set bits, want %d", got, want)
+	}
+}
+
+func TestBloomFilter_TuneK(t *testing.T) {
+	// Overfill a filter; fewer hash functions then give a lower rate.
+	bf := NewBloomFilter[int](1000, 0.01)
+	for i := range 4000 {
+		bf.Add(i)
+	}
+	before := bf.ActualFalsePositiveRate()
+	k, rebuild := bf.TuneK()
+	if rebuild {
+		t.Error("reducing k should not need a rebuild")
+	}
+	if got, want := k, uint(2); got != want { // 9586/4000·ln 2 ≈ 1.66
+		t.Errorf("got k=%d, want %d", got, want)
+	}
+	if len(bf.seeds) != int(k) {
+		t.Errorf("got %d seeds, want %d", len(bf.seeds), k)
+	}
+	for i := range 4000 {
+		if !bf.Contains(i) {
+			t.Fatalf("%d should still be in the filter", i)
+		}
+	}
+	if after := FalsePositiveRate(bf.m, k, 4000); after >= before {
+		t.Errorf("got expected FPR %v after tuning, want less than %v", after, before)
+	}
+
+	// An underfilled filter needs more hash functions, and a rebuild.
+	bf = NewBloomFilter[int](1000, 0.01)
+	for i := range 100 {
+		bf.Add(i)
+	}
+	k, rebuild = bf.TuneK()
+	if !rebuild || k <= 7 || bf.entries != 0 {
+		t.Errorf("got k=%d, rebuild=%v, entries=%d; want more hash functions and a reset filter", k, rebuild, bf.entries)
+	}
+
+	// New hash functions should stay separated by domain, matching a filter
+	// created with the new number of hash functions.
+	var tuned [2]*Filter[int]
+	for i, domain := range []string{"a", "b"} {
+		tuned[i] = NewBloomFilter[int](1000, 0.01, WithFNVHash(), WithDomain(domain))
+		for j := range 100 {
+			tuned[i].Add(j)
+		}
+		k, _ = tuned[i].TuneK()
+		want, err := NewBloomFilterWithSeeds[int](tuned[i].m, k, fnvSeeds(k), WithFNVHash(), WithDomain(domain))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(tuned[i].seeds, want.seeds) {
+			t.Errorf("domain %q: tuned seeds should match a new filter's", domain)
+		}
+	}
+	for i, seed := range tuned[0].seeds {
+		if slices.Contains(tuned[1].seeds, seed) {
+			t.Errorf("seed %d is shared between domains", i)
+		}
+	}
+
+	// An empty filter is unchanged.
+	bf = NewBloomFilter[int](1000, 0.01)
+	if k, rebuild := bf.TuneK(); k != 7 || rebuild {
+		t.Errorf("got k=%d, rebuild=%v for an empty filter, want 7, false", k, rebuild)
+	}
+}
+
+func TestBloomFilter_PeakFillRatio(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	for i := range 1000 {
+		bf.Add(i)
+	}
+	peak := bf.FillRatio()
+	if got := bf.PeakFillRatio(); got != peak {
+		t.Errorf("got peak fill ratio %v, want %v", got, peak)
+	}
+
+	bf.Reset()
+	if bf.FillRatio() != 0 || bf.entries != 0 || bf.Contains(1) {
+		t.Error("filter should be empty after Reset")
+	}
+	bf.Add(1)
+	if got := bf.PeakFillRatio(); got != peak {
+		t.Errorf("got peak fill ratio %v after reset, want %v", got, peak)
+	}
+
+	// A fuller filter after the reset raises the peak.
+	for i := range 2000 {
+		bf.Add(i)
+	}
+	if got := bf.PeakFillRatio(); got <= peak || got != bf.FillRatio() {
+		t.Errorf("got peak fill ratio %v, want current fill ratio %v", got, bf.FillRatio())
+	}
+}
+
+func TestBloomFilter_EstimatedDuplicateRate(t *testing.T) {
+	bf := NewBloomFilter[int](10000, 0.01)
+	if got := bf.EstimatedDuplicateRate(); got != 0 {
+		t.Errorf("got duplicate rate %v for an empty filter, want 0", got)
+	}
+
+	// 7000 distinct items, and 3000 repeats.
+	for i := range 10000 {
+		bf.Add(i % 7000)
+	}
+	if got := bf.EstimatedCardinality(); math.Abs(got-7000) > 200 {
+		t.Errorf("got estimated cardinality %v, want about 7000", got)
+	}
+	if got := bf.EstimatedDuplicateRate(); math.Abs(got-0.3) > 0.03 {
+		t.Errorf("got duplicate rate %v, want about 0.3", got)
+	}
+
+	full := NewBloomFilter[int](10, 0.01)
+	for i := range full.bits {
+		full.bits[i] = ^uint64(0)
+	}
+	if got := full.EstimatedCardinality(); !math.IsInf(got, 1) {
+		t.Errorf("got estimated cardinality %v for a full filter, want +Inf", got)
+	}
+}
+
+func TestBloomFilter_DensityMap(t *testing.T) {
+	bf, err := NewBloomFilterWithSeeds[int](1000, 1, []uint64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fill the first half of the bits, and every other bit of the rest.
+	for i := range uint(1000) {
+		if i < 500 || i%2 == 0 {
+			bf.bits[i/64] |= 1 << (i % 64)
+		}
+	}
+
+	grid := bf.DensityMap(5, 2)
+	want := [][]uint8{{255, 255, 255, 255, 255}, {128, 128, 128, 128, 128}}
+	if len(grid) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(grid), len(want))
+	}
+	for row := range want {
+		if !slices.Equal(grid[row], want[row]) {
+			t.Errorf("row %d: got %v, want %v", row, grid[row], want[row])
+		}
+	}
+
+	// More cells than bits leaves some cells empty.
+	small, err := NewBloomFilterWithSeeds[int](3, 1, []uint64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	small.bits[0] = 0b111
+	if got, want := small.DensityMap(6, 1)[0], []uint8{0, 255, 0, 255, 0, 255}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestBloomFilter_OptimalCapacity(t *testing.T) {
+	for _, n := range []uint{100, 1000, 100000} {
+		bf := NewBloomFilter[int](n, 0.01)
+		// k is rounded up, so the optimal capacity is slightly lower.
+		if got := bf.OptimalCapacity(); got > n || got < n*9/10 {
+			t.Errorf("got optimal capacity %d, want approximately %d", got, n)
+		}
+	}
+
+	bf := NewBloomFilterFixedK[int](1000, 0.01, 1)
+	if got := bf.OptimalCapacity(); got < 10*1000 {
+		t.Errorf("got optimal capacity %d for k=1, want much more than 1000", got)
+	}
+}
+
+func TestMinBitsPerElement(t *testing.T) {
+	for _, tt := range []struct {
+		fpr, want float64
+	}{
+		{0.5, 1 / math.Ln2},
+		{0.01, 9.585058},
+		{0.001, 14.377588},
+	} {
+		if got := MinBitsPerElement(tt.fpr); math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("MinBitsPerElement(%v): got %v, want %v", tt.fpr, got, tt.want)
+		}
+	}
+}
+
+func TestBloomFilter_BitsPerElement(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	if got := bf.BitsPerElement(); !math.IsInf(got, 1) {
+		t.Errorf("got %v for empty filter, want +Inf", got)
+	}
+
+	for i := range 1000 {
+		bf.Add(i)
+	}
+	// At capacity, the filter should be close to the optimum.
+	optimal := MinBitsPerElement(0.01)
+	if got := bf.BitsPerElement(); math.Abs(got-optimal) > 0.01 {
+		t.Errorf("got %v bits per element, want %v", got, optimal)
+	}
+}
+
+func TestBloomFilter_RemainingCapacity_Unlimited(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 1)
+	bf.Add(1)
+	if got := bf.RemainingCapacity(); got != math.MaxUint {
+		t.Errorf("got remaining capacity %d for a target rate of one, want MaxUint", got)
+	}
+}
+
+func TestBloomFilter_RemainingCapacity(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	// k is rounded up, so the capacity at the target is slightly lower.
+	if got := bf.RemainingCapacity(); got > 1000 || got < 900 {
+		t.Errorf("got remaining capacity %d for an empty filter, want about 1000", got)
+	}
+
+	for i := range 500 {
+		bf.Add(i)
+	}
+	remaining := bf.RemainingCapacity()
+	if remaining > 500 || remaining < 400 {
+		t.Errorf("got remaining capacity %d, want about 500", remaining)
+	}
+	for i := range remaining {
+		bf.Add(int(500 + i))
+	}
+	if fpr := bf.EstimatedFalsePositiveRate(); fpr > 0.01 {
+		t.Errorf("got estimated FPR %v after filling to capacity, want <= 0.01", fpr)
+	}
+	bf.Add(-1)
+	if got := bf.RemainingCapacity(); got != 0 {
+		t.Errorf("got remaining capacity %d when full, want 0", got)
+	}
+
+	// Without entry counting, the number of items is estimated.
+	bf = NewBloomFilter[int](1000, 0.01, WithoutEntryCounting())
+	for i := range 500 {
+		bf.Add(i)
+	}
+	if got := bf.RemainingCapacity(); got > 550 || got < 350 {
+		t.Errorf("got remaining capacity %d without entry counting, want about 500", got)
+	}
+}
+
+func TestBloomFilter_BitsSavedBy(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	if got := bf.BitsSavedBy(0.01); got != 0 {
+		t.Errorf("got %d bits saved at the same FPR, want 0", got)
+	}
+
+	relaxed, _ := bloomParams(1000, 0.05)
+	if got, want := bf.BitsSavedBy(0.05), int(bf.m-relaxed); got != want {
+		t.Errorf("got %d bits saved at 0.05, want %d", got, want)
+	}
+	if got := bf.BitsSavedBy(0.001); got >= 0 {
+		t.Errorf("got %d bits saved by tightening, want a negative number", got)
+	}
+
+	restored, err := NewBloomFilterWithSeeds[int](bf.m, uint(len(bf.seeds)), bf.Seeds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.BitsSavedBy(0.05); got <= 0 {
+		t.Errorf("got %d bits saved for a filter of unknown size, want a positive number", got)
+	}
+}
+
+func TestBloomFilter_Health(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01)
+	for i := range 1000 {
+		bf.Add(i)
+	}
+	if ok, reason := bf.Health(); !ok {
+		t.Errorf("filter at capacity should be healthy, got reason %q", reason)
+	}
+	if fill := bf.FillRatio(); fill < 0.45 || fill > 0.55 {
+		t.Errorf("got fill ratio %v at capacity, want approximately 0.5", fill)
+	}
+
+	for i := 1000; i < 3000; i++ {
+		bf.Add(i)
+	}
+	ok, reason := bf.Health()
+	if ok {
+		t.Fatal("overfilled filter should be unhealthy")
+	}
+	for _, want := range []string{"more than the 1000", "fill ratio", "false positive rate"} {
+		if !strings.Contains(reason, want) {
+			t.Errorf("reason %q should mention %q", reason, want)
+		}
+	}
+}
+
+func TestBloomFilter_WithSaturationCheck(t *testing.T) {
+	var fills []float64
+	bf := NewBloomFilter[int](100, 0.01, WithSaturationCheck(1000), WithHooks(Hooks{
+		OnSaturated: func(fill float64) { fills = append(fills, fill) },
+	}))
+
+	// Adding the designed number of items leaves the filter half full.
+	for i := range 100 {
+		bf.Add(i)
+	}
+	for i := range 900 {
+		bf.Contains(i)
+	}
+	if len(fills) != 0 {
+		t.Errorf("got %d saturation callbacks for a healthy filter, want 0", len(fills))
+	}
+
+	// Overfill it without going through Add, as if it were loaded from
+	// disk; only queries are made from now on.
+	for i := range bf.bits {
+		bf.bits[i] = ^uint64(0)
+	}
+	for i := range 1999 {
+		bf.Contains(i)
+	}
+	if len(fills) != 1 {
+		t.Fatalf("got %d saturation callbacks, want 1", len(fills))
+	}
+	if fills[0] != 1 {
+		t.Errorf("got fill ratio %v, want 1", fills[0])
+	}
+	bf.Contains(0)
+	if len(fills) != 2 {
+		t.Errorf("got %d saturation callbacks, want 2", len(fills))
+	}
+}
+
+func TestBloomFilter_WithSaturationCheck_Batch(t *testing.T) {
+	var saturated int
+	bf := NewBloomFilter[int](100, 0.01, WithSaturationCheck(100), WithHooks(Hooks{
+		OnSaturated: func(float64) { saturated++ },
+	}))
+	for i := range bf.bits {
+		bf.bits[i] = ^uint64(0)
+	}
+
+	// Each item in a batch counts as a call.
+	items := make([]int, 250)
+	bf.ContainsMany(items)
+	if saturated != 2 {
+		t.Errorf("got %d saturation callbacks from ContainsMany, want 2", saturated)
+	}
+
+	for range 50 {
+		NewQuery(1).In(bf)
+	}
+	if saturated != 3 {
+		t.Errorf("got %d saturation callbacks after Query.In, want 3", saturated)
+	}
+}
+
+func TestEstimatedMemory(t *testing.T) {
+	for _, n := range []uint{0, 1, 1000, 123456} {
+		for _, p := range []float64{0.5, 0.01, 1e-6} {
+			if got, want := EstimatedMemory(n, p), NewBloomFilter[int](n, p).MemoryUsage(); got != want {
+				t.Errorf("EstimatedMemory(%d, %v) = %d, want %d", n, p, got, want)
+			}
+		}
+	}
+}
+
+func TestRecommendShards(t *testing.T) {
+	const items, fpr = 1000000, 0.01
+	total := NewBloomFilter[int](items, fpr).MemoryUsage()
+
+	if got := RecommendShards(items, fpr, total); got != 1 {
+		t.Errorf("got %d shards when one filter fits, want 1", got)
+	}
+
+	shards := RecommendShards(items, fpr, total/10)
+	if shards < 10 || shards > 11 {
+		t.Errorf("got %d shards for a tenth of the memory, want 10 or 11", shards)
+	}
+	perShard := uint((items + shards - 1) / shards)
+	if size := NewBloomFilter[int](perShard, fpr).MemoryUsage(); size > total/10 {
+		t.Errorf("got %d bytes per shard, want at most %d", size, total/10)
+	}
+	perShard = uint((items + shards - 2) / (shards - 1))
+	if size := NewBloomFilter[int](perShard, fpr).MemoryUsage(); size <= total/10 {
+		t.Errorf("%d shards would also fit, want the smallest number", shards-1)
+	}
+
+	if got := RecommendShards(items, fpr, 1); got != 0 {
+		t.Errorf("got %d shards for an impossible cap, want 0", got)
+	}
+}
+
+func TestBloomFilter_SnapshotStats(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01, WithFNVHash(), WithIntervalStats())
+	for i := range 100 {
+		bf.Add(i)
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				bf.Contains(i)
+			}
+		}()
+	}
+	wg.Wait()
+	bf.Contains(-1)
+
+	want := IntervalStats{Adds: 100, Contains: 201, Positives: 200}
+	if got := bf.SnapshotStats(); got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+	if got := bf.SnapshotStats(); got != (IntervalStats{}) {
+		t.Errorf("got stats %+v after snapshot, want zero", got)
+	}
+
+	if got := NewBloomFilter[int](1000, 0.01).SnapshotStats(); got != (IntervalStats{}) {
+		t.Errorf("got stats %+v without WithIntervalStats, want zero", got)
+	}
+}
+
+func TestBloomFilter_SnapshotStats_Batch(t *testing.T) {
+	bf := NewBloomFilter[int](1000, 0.01, WithFNVHash(), WithIntervalStats())
+	for i := range 100 {
+		bf.Add(i)
+	}
+
+	bf.ContainsMany([]int{0, 1, -1})
+	bf.ContainsProbability(5)
+	NewQuery(7).In(bf)
+
+	want := IntervalStats{Adds: 100, Contains: 5, Positives: 4}
+	if got := bf.SnapshotStats(); got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+}
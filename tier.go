@@ -0,0 +1,48 @@
+package bloom
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Tier holds a set of prebuilt filters of different capacities, such as for
+// 1,000, 10,000 and 100,000 items, and picks the smallest one that can hold a
+// given number of items at its designed false positive rate.
+//
+// A Tier is safe for concurrent use, since it doesn't change after it is
+// created, but its filters must not be modified while the Tier is being
+// queried.
+type Tier[T comparable] struct {
+	filters []*Filter[T] // sorted by expectedItems
+}
+
+// NewTier creates a Tier holding the given filters, each keyed by the number
+// of items it was sized for. If several filters were sized for the same
+// number of items, the first of them is used.
+//
+// It panics if a filter's capacity isn't known, such as for filters created
+// by [NewBloomFilterWithSeeds].
+func NewTier[T comparable](filters ...*Filter[T]) *Tier[T] {
+	sorted := slices.Clone(filters)
+	for _, bf := range sorted {
+		if bf.expectedItems == 0 {
+			panic("bloom: filter capacity is unknown")
+		}
+	}
+	slices.SortStableFunc(sorted, func(a, b *Filter[T]) int {
+		return cmp.Compare(a.expectedItems, b.expectedItems)
+	})
+	return &Tier[T]{filters: sorted}
+}
+
+// For returns the filter sized for the fewest items that is sized for at least
+// size items, or nil if every filter is too small.
+func (t *Tier[T]) For(size uint) *Filter[T] {
+	i, _ := slices.BinarySearchFunc(t.filters, size, func(bf *Filter[T], size uint) int {
+		return cmp.Compare(bf.expectedItems, size)
+	})
+	if i == len(t.filters) {
+		return nil
+	}
+	return t.filters[i]
+}
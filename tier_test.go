@@ -0,0 +1,44 @@
+package bloom
+
+import "testing"
+
+func TestTier(t *testing.T) {
+	large := NewBloomFilter[int](100000, 0.01)
+	small := NewBloomFilter[int](1000, 0.01)
+	medium := NewBloomFilter[int](10000, 0.01)
+	duplicate := NewBloomFilter[int](10000, 0.01)
+	tier := NewTier(large, small, medium, duplicate)
+
+	tests := []struct {
+		size uint
+		want *Filter[int]
+	}{
+		{size: 0, want: small},
+		{size: 1000, want: small},
+		{size: 1001, want: medium},
+		{size: 50000, want: large},
+		{size: 100000, want: large},
+		{size: 100001, want: nil},
+	}
+	for _, tt := range tests {
+		if got := tier.For(tt.size); got != tt.want {
+			t.Errorf("For(%d) = filter for %v items, want %v", tt.size, capacityOf(got), capacityOf(tt.want))
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for filter of unknown capacity")
+		}
+	}()
+	unknown, _ := NewBloomFilterWithSeeds[int](1000, 1, []uint64{1})
+	NewTier(small, unknown)
+}
+
+// capacityOf returns the number of items bf was sized for, or nil.
+func capacityOf(bf *Filter[int]) any {
+	if bf == nil {
+		return nil
+	}
+	return bf.expectedItems
+}
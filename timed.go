@@ -0,0 +1,125 @@
+package bloom
+
+import "time"
+
+const (
+	// timedTicksPerTTL is the resolution of a TimedFilter's timestamps.
+	timedTicksPerTTL = 1024
+
+	// timedPruneCells is how many cells each TimedFilter.Add checks for
+	// expiry, in addition to the cells of the item it adds.
+	timedPruneCells = 8
+)
+
+// TimedFilter is a Bloom filter whose items expire: [TimedFilter.Contains]
+// reports an item as present only if it was added within the filter's TTL,
+// giving "seen within the last N seconds" semantics, such as for rate limiting
+// or abuse detection.
+//
+// Each position of the filter holds a timestamp, rather than a single bit, of
+// the last time an item that maps to it was added. An item is present while
+// all of its positions have been touched within the TTL, so items added
+// within the TTL are never reported as absent; as with any Bloom filter,
+// other items may be reported as present, including ones that have expired
+// but whose positions were refreshed by other items. Timestamps have a
+// resolution of 1/1024 of the TTL, so expiry is accurate to within that.
+//
+// Timestamps are 32 bits each, so a TimedFilter uses 32 times as much memory
+// as a [Filter] with the same parameters. Expired positions are cleared
+// lazily by Add, which also checks a few other positions each time.
+type TimedFilter[T comparable] struct {
+	cells      []uint32 // tick of the last add to each position; zero if never
+	seeds      []uint64 // k different seeds for k hash functions
+	resolution time.Duration
+	start      time.Time
+	hand       int    // next cell for Add to check for expiry
+	lastAdd    uint64 // tick of the last Add
+
+	now func() time.Time
+}
+
+// NewTimedFilter creates a new, empty TimedFilter optimized for the expected
+// number of items added within any ttl, and desired false positive rate.
+//
+// It panics if ttl is not positive, and otherwise under the same conditions as
+// [NewBloomFilter].
+func NewTimedFilter[T comparable](expectedItems uint, falsePositiveRate float64, ttl time.Duration) *TimedFilter[T] {
+	if ttl <= 0 {
+		panic("bloom: TTL must be positive")
+	}
+	m, k := bloomParams(expectedItems, falsePositiveRate)
+	return &TimedFilter[T]{
+		cells:      make([]uint32, m),
+		seeds:      randomSeeds(k),
+		resolution: max(ttl/timedTicksPerTTL, 1),
+		start:      time.Now(),
+		now:        time.Now,
+	}
+}
+
+// tick returns the current time in units of tf.resolution since tf was
+// created, starting from one so that zero can mark unused cells.
+//
+// Cells hold only the low 32 bits of a tick, which wrap around after about
+// four million TTLs, and are compared by unsigned subtraction. That stays
+// correct as long as no cell goes that long without being checked: Add clears
+// expired cells as it sweeps the array, and Contains treats the whole filter
+// as expired if nothing has been added within the TTL.
+func (tf *TimedFilter[T]) tick() uint64 {
+	return uint64(tf.now().Sub(tf.start)/tf.resolution) + 1
+}
+
+// cellFresh reports whether a cell last touched at stamp is within the TTL at now.
+func cellFresh(stamp, now uint32) bool {
+	return stamp != 0 && now-stamp < timedTicksPerTTL
+}
+
+// Add inserts an item into the filter, with the current time.
+//
+// This method is not safe for concurrent use.
+func (tf *TimedFilter[T]) Add(item T) {
+	tick := tf.tick()
+	tf.lastAdd = tick
+	now := uint32(tick)
+	for _, seed := range tf.seeds {
+		tf.cells[tf.position(item, seed)] = now
+	}
+
+	for range min(timedPruneCells, len(tf.cells)) {
+		if !cellFresh(tf.cells[tf.hand], now) {
+			tf.cells[tf.hand] = 0
+		}
+		tf.hand = (tf.hand + 1) % len(tf.cells)
+	}
+}
+
+// Contains tests whether an item might have been added within the filter's
+// TTL. False positives are possible, but false negatives are not.
+//
+// This method can be called concurrently with other calls to itself, but not
+// [TimedFilter.Add].
+func (tf *TimedFilter[T]) Contains(item T) bool {
+	tick := tf.tick()
+	if tick-tf.lastAdd >= timedTicksPerTTL {
+		return false
+	}
+	now := uint32(tick)
+	for _, seed := range tf.seeds {
+		if !cellFresh(tf.cells[tf.position(item, seed)], now) {
+			return false
+		}
+	}
+	return true
+}
+
+// TTL returns how long items remain in the filter after they are added, to
+// within the filter's resolution.
+func (tf *TimedFilter[T]) TTL() time.Duration {
+	return tf.resolution * timedTicksPerTTL
+}
+
+// position returns the index of the cell that item maps to for the hash
+// function identified by seed.
+func (tf *TimedFilter[T]) position(item T, seed uint64) uint64 {
+	return reduce(maphashItem(item, seed), uint(len(tf.cells)))
+}
@@ -0,0 +1,81 @@
+package bloom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimedFilter(t *testing.T) {
+	tf := NewTimedFilter[string](1000, 0.01, time.Minute)
+	now := tf.start
+	tf.now = func() time.Time { return now }
+
+	if got := tf.TTL(); got < time.Minute-time.Second || got > time.Minute {
+		t.Errorf("got TTL %v, want about %v", got, time.Minute)
+	}
+
+	tf.Add("apple")
+	now = now.Add(30 * time.Second)
+	tf.Add("banana")
+	for _, fruit := range []string{"apple", "banana"} {
+		if !tf.Contains(fruit) {
+			t.Errorf("'%s' should be in the filter within the TTL", fruit)
+		}
+	}
+
+	// Only banana was added within the last minute.
+	now = now.Add(45 * time.Second)
+	if tf.Contains("apple") {
+		t.Error("'apple' should have expired")
+	}
+	if !tf.Contains("banana") {
+		t.Error("'banana' should still be in the filter")
+	}
+
+	// Adding an item again refreshes it.
+	tf.Add("apple")
+	now = now.Add(45 * time.Second)
+	if !tf.Contains("apple") || tf.Contains("banana") {
+		t.Error("'apple' should have been refreshed, and 'banana' expired")
+	}
+
+	// After a long idle period, nothing is present, even once the
+	// timestamps have wrapped around.
+	now = now.Add(tf.resolution << 32)
+	if tf.Contains("apple") {
+		t.Error("'apple' should have expired after a long idle period")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for non-positive TTL")
+		}
+	}()
+	NewTimedFilter[string](1000, 0.01, 0)
+}
+
+func TestTimedFilter_Prune(t *testing.T) {
+	tf := NewTimedFilter[int](100, 0.01, time.Minute)
+	now := tf.start
+	tf.now = func() time.Time { return now }
+
+	for i := range 100 {
+		tf.Add(i)
+	}
+	now = now.Add(2 * time.Minute)
+
+	// Enough adds for the sweep to cover every cell clears every expired
+	// one, leaving only the cells of the newly added item.
+	for range len(tf.cells)/timedPruneCells + 1 {
+		tf.Add(-1)
+	}
+	var used int
+	for _, stamp := range tf.cells {
+		if stamp != 0 {
+			used++
+		}
+	}
+	if used > len(tf.seeds) {
+		t.Errorf("got %d cells in use, want at most %d", used, len(tf.seeds))
+	}
+}
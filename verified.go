@@ -0,0 +1,53 @@
+package bloom
+
+// VerifiedSet is an exact set that uses a Bloom filter as a fast negative
+// check: Contains returns false immediately for most absent items, and
+// confirms every positive against a map of the items added. This eliminates
+// false positives entirely, at the cost of storing every item, which suits
+// sets where lookups of absent items are common and the map is large or slow
+// to probe.
+type VerifiedSet[T comparable] struct {
+	filter *Filter[T]
+	items  map[T]struct{}
+}
+
+// NewVerifiedSet creates a new, empty VerifiedSet whose filter is optimized
+// for the expected number of items and desired false positive rate; the rate
+// determines how often a lookup of an absent item falls through to the map.
+//
+// It panics under the same conditions as [NewBloomFilter].
+func NewVerifiedSet[T comparable](expectedItems uint, falsePositiveRate float64, opts ...Option) *VerifiedSet[T] {
+	return &VerifiedSet[T]{
+		filter: NewBloomFilter[T](expectedItems, falsePositiveRate, opts...),
+		items:  make(map[T]struct{}, expectedItems),
+	}
+}
+
+// Add inserts an item into the set.
+//
+// This method is not safe for concurrent use.
+func (vs *VerifiedSet[T]) Add(item T) {
+	if _, ok := vs.items[item]; ok {
+		return
+	}
+	vs.items[item] = struct{}{}
+	vs.filter.Add(item)
+}
+
+// Contains reports whether an item is in the set. Unlike a Bloom filter, the
+// result is exact.
+//
+// This method can be called concurrently with other calls to itself, but not
+// [VerifiedSet.Add].
+func (vs *VerifiedSet[T]) Contains(item T) bool {
+	if !vs.filter.Contains(item) {
+		return false
+	}
+	_, ok := vs.items[item]
+	return ok
+}
+
+// Len returns the number of distinct items in the set.
+func (vs *VerifiedSet[T]) Len() int {
+	return len(vs.items)
+}
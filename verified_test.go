@@ -0,0 +1,35 @@
+package bloom
+
+import (
+	"testing"
+)
+
+func TestVerifiedSet(t *testing.T) {
+	// A filter with a high false positive rate, so that the map must
+	// reject many positives.
+	vs := NewVerifiedSet[int](100, 0.5)
+	for i := range 100 {
+		vs.Add(i)
+		vs.Add(i)
+	}
+	if got, want := vs.Len(), 100; got != want {
+		t.Errorf("got %d items, want %d", got, want)
+	}
+	if got, want := vs.filter.entries, uint(100); got != want {
+		t.Errorf("got %d filter entries, want %d", got, want)
+	}
+
+	var filterPositives int
+	for i := range 10000 {
+		want := i < 100
+		if got := vs.Contains(i); got != want {
+			t.Fatalf("Contains(%d) = %v, want %v", i, got, want)
+		}
+		if !want && vs.filter.Contains(i) {
+			filterPositives++
+		}
+	}
+	if filterPositives == 0 {
+		t.Error("expected some false positives from the filter to be rejected")
+	}
+}